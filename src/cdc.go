@@ -1,14 +1,11 @@
 package main
 
 import (
-	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
-	"os"
-	"os/signal"
 	"strings"
-	"syscall"
 	"time"
 	"unicode/utf16"
 	"unicode/utf8"
@@ -74,46 +71,131 @@ func decodeString(b []byte) string {
 	return string(b)
 }
 
-func runCDC(cfg Config, srcDB, tgtDB *sql.DB, startFile string, startPos uint32) error {
+func runCDC(sc *ShutdownCoordinator, cfg Config, srcDB, tgtDB *sql.DB, startFile string, startPos uint32, startGTIDSet, gtidFlavor string) error {
 	// prepare checkpoint table
 	if err := EnsureCheckpointTable(tgtDB, cfg.CheckpointTable); err != nil {
 		return err
 	}
 
-	// create binlog syncer config
+	cutover, err := NewCutoverCoordinator(cfg, srcDB, tgtDB)
+	if err != nil {
+		return fmt.Errorf("init cutover coordinator: %v", err)
+	}
+	throttler := NewThrottler(cfg, srcDB)
+	defer throttler.Close()
+
+	schemaTracker, err := NewSchemaTracker(cfg, tgtDB)
+	if err != nil {
+		return fmt.Errorf("init schema tracker: %v", err)
+	}
+
+	// create binlog syncer config from the structured DSN rather than
+	// re-parsing it once per field via the extractXFromDSN wrappers.
+	srcInfo, err := parseDSN(cfg.SrcDSN)
+	if err != nil {
+		return fmt.Errorf("parse source DSN: %v", err)
+	}
 	syncerCfg := replication.BinlogSyncerConfig{
 		ServerID: cfg.ServerID,
 		Flavor:   "mysql",
-		Host:     extractHostFromDSN(cfg.SrcDSN),
-		Port:     extractPortFromDSN(cfg.SrcDSN),
-		User:     extractUserFromDSN(cfg.SrcDSN),
-		Password: extractPassFromDSN(cfg.SrcDSN),
+		Host:     srcInfo.Host,
+		Port:     srcInfo.Port,
+		User:     srcInfo.User,
+		Password: srcInfo.Passwd,
 	}
 
 	syncer := replication.NewBinlogSyncer(syncerCfg)
-	pos := mysql.Position{Name: startFile, Pos: startPos}
-	streamer, err := syncer.StartSync(pos)
+
+	var streamer *replication.BinlogStreamer
+	if cfg.ReplicationMode == "gtid" {
+		// GTID mode is meant to survive source failover (replica promotion),
+		// where the persisted file/pos are meaningless. Falling back to
+		// them silently would defeat that, so a missing/unparseable GTID
+		// checkpoint is a hard error here instead.
+		if startGTIDSet == "" {
+			return fmt.Errorf("replication mode is gtid but no GTID set is available to resume from")
+		}
+		flavor := gtidFlavor
+		if flavor == "" {
+			flavor = "mysql"
+		}
+		gset, gerr := mysql.ParseGTIDSet(flavor, startGTIDSet)
+		if gerr != nil {
+			return fmt.Errorf("replication mode is gtid but stored GTID set %q is unparseable: %v", startGTIDSet, gerr)
+		}
+		log.Printf("Resuming CDC via GTID set: %s", startGTIDSet)
+		streamer, err = syncer.StartSyncGTID(gset)
+	} else if startGTIDSet != "" {
+		flavor := gtidFlavor
+		if flavor == "" {
+			flavor = "mysql"
+		}
+		gset, gerr := mysql.ParseGTIDSet(flavor, startGTIDSet)
+		if gerr != nil {
+			log.Printf("Warning: failed to parse stored GTID set (%v), falling back to file/pos", gerr)
+			streamer, err = syncer.StartSync(mysql.Position{Name: startFile, Pos: startPos})
+		} else {
+			log.Printf("Resuming CDC via GTID set: %s", startGTIDSet)
+			streamer, err = syncer.StartSyncGTID(gset)
+		}
+	} else {
+		streamer, err = syncer.StartSync(mysql.Position{Name: startFile, Pos: startPos})
+	}
 	if err != nil {
 		return err
 	}
 	defer syncer.Close()
 
-	// graceful shutdown
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	ticker := time.NewTicker(time.Duration(cfg.CheckpointPeriod) * time.Second)
 	defer ticker.Stop()
-	
-	ctx := context.Background()
+
+	ctx := sc.Context()
+
+	// sink owns where decoded row ops actually go - MySQL by default, or a
+	// Kafka/Debezium producer when CDCSinkDSN is set - and, for the MySQL
+	// sink, buffers the DML for the source transaction currently in flight so
+	// it commits atomically at the matching XIDEvent instead of each row
+	// autocommitting on its own. See cdc_sink.go.
+	sink, err := NewCDCSink(cfg, tgtDB)
+	if err != nil {
+		return fmt.Errorf("init CDC sink: %v", err)
+	}
+	defer sink.Close()
+
+	// streamGTIDSet tracks the GTID set the syncer itself has assembled from
+	// PreviousGTIDsEvent/GTIDEvent payloads as transactions are streamed,
+	// surfaced on each XIDEvent's GSet field. Unlike GetSourceGTIDSet, which
+	// queries the source's current @@GLOBAL.gtid_executed, this reflects
+	// exactly the transactions this run has actually read and applied, so a
+	// checkpoint written from it can never advance past what was replayed -
+	// a real risk with the polling query if other sessions commit on the
+	// source between an event being applied and the next checkpoint tick.
+	// Empty until the first XIDEvent arrives, so checkpoints fall back to
+	// GetSourceGTIDSet until then.
+	var streamGTIDSet string
 
 	for {
 		select {
-		case <-sigs:
-			log.Println("Shutdown signal received, exiting CDC")
+		case <-ctx.Done():
+			log.Println("Shutdown requested, draining in-flight CDC apply work")
+			sc.WaitForDrain()
+			if rows, _ := sink.Pending(); rows > 0 {
+				// Its source transaction never reached XID, so it was
+				// never safe to flush; a resumed run reapplies it in full
+				// once the stream catches back up to startFile/startPos.
+				log.Printf("Discarding %d buffered row op(s) from an in-progress, uncommitted source transaction", rows)
+			}
 			// final checkpoint
-			if err := WriteCheckpoint(tgtDB, cfg.CheckpointTable, keyFor(cfg), startFile, startPos); err != nil {
+			gtidSet := streamGTIDSet
+			if gtidSet == "" {
+				gtidSet, _ = GetSourceGTIDSet(srcDB)
+			}
+			if err := WriteCheckpoint(tgtDB, cfg.CheckpointTable, keyFor(cfg), startFile, startPos, gtidSet, "mysql"); err != nil {
 				log.Println("Error writing final checkpoint:", err)
+			} else {
+				globalMetrics.UpdateCheckpoint(startFile, startPos)
 			}
+			log.Println("Final checkpoint written, exiting CDC")
 			return nil
 		default:
 			ev, err := streamer.GetEvent(ctx)
@@ -134,16 +216,88 @@ func runCDC(cfg Config, srcDB, tgtDB *sql.DB, startFile string, startPos uint32)
 					// ignore
 					continue
 				}
-				// handle row event: e.Header.EventType
-				if err := handleRowsEvent(cfg, tgtDB, e, ev.Header); err != nil {
-					log.Println("Error applying row event:", err)
-					// retry logic could be added here with backoff
+				if terr := throttler.Wait(); terr != nil {
+					return terr
+				}
+				globalMetrics.UpdateThrottleReason(throttler.Reason())
+				// Decode the row images once here, then hand the resulting
+				// ops to the sink; it applies or buffers them however its
+				// destination needs. They commit together at this
+				// transaction's XIDEvent.
+				ops, err := decodeRowsEvent(cfg, tgtDB, schemaTracker, e, ev.Header)
+				if err != nil {
+					return fmt.Errorf("decode row event: %v", err)
+				}
+				warnOversizedRows(cfg, ops, startFile, ev.Header.LogPos)
+				for _, op := range ops {
+					if err := sink.Apply(ctx, op); err != nil {
+						return fmt.Errorf("apply row op: %v", err)
+					}
+				}
+				// A single source transaction touching millions of rows
+				// would otherwise buffer unboundedly; split it at a safe
+				// row boundary once it crosses the configured cap, same as
+				// a normal XID flush but without a checkpoint write, since
+				// the source transaction itself hasn't committed yet.
+				if rows, bytes := sink.Pending(); rows >= cfg.BatchMaxRows || bytes >= cfg.BatchMaxBytes {
+					release := sc.TrackApply()
+					applyStart := time.Now()
+					err := sink.Flush(ctx)
+					cdcApplyLatency.Observe(time.Since(applyStart).Seconds())
+					release()
+					if err != nil {
+						return fmt.Errorf("flush oversized transaction: %v", err)
+					}
 				}
 			case *replication.RotateEvent:
 				// update startFile
 				pos := uint32(0)
 				startFile = string(e.NextLogName)
 				startPos = pos
+			case *replication.XIDEvent:
+				// Marks the end of a transaction: flush its buffered DML
+				// and the checkpoint covering it in one tgtDB transaction,
+				// so a crash can never leave one persisted without the
+				// other. e.GSet is the syncer's running GTID set (built
+				// from PreviousGTIDsEvent/GTIDEvent) as of exactly this
+				// commit, so it's safe to persist alongside it.
+				if e.GSet != nil {
+					streamGTIDSet = e.GSet.String()
+				}
+				logPos := ev.Header.LogPos
+				release := sc.TrackApply()
+				applyStart := time.Now()
+				err := sink.Commit(ctx, CDCCheckpoint{
+					Table:   cfg.CheckpointTable,
+					Key:     keyFor(cfg),
+					File:    startFile,
+					Pos:     logPos,
+					GTIDSet: streamGTIDSet,
+					Flavor:  "mysql",
+				})
+				cdcApplyLatency.Observe(time.Since(applyStart).Seconds())
+				release()
+				if err != nil {
+					return fmt.Errorf("flush transaction at XID %d: %v", e.XID, err)
+				}
+				startPos = logPos
+			case *replication.QueryEvent:
+				q := strings.TrimSpace(string(e.Query))
+				if strings.EqualFold(q, "BEGIN") {
+					// Transaction start marker only; rows are buffered
+					// until the matching XIDEvent above.
+					continue
+				}
+				// Any other QueryEvent is DDL, which MySQL commits
+				// implicitly - any transaction on the tracked table must
+				// already have reached its XIDEvent by now, so this is
+				// just a defensive flush in case the buffer is non-empty.
+				if err := sink.Flush(ctx); err != nil {
+					return fmt.Errorf("flush before DDL: %v", err)
+				}
+				if err := schemaTracker.HandleQueryEvent(string(e.Schema), string(e.Query)); err != nil {
+					return fmt.Errorf("schema tracker: %v", err)
+				}
 			case *replication.FormatDescriptionEvent:
 				// ignore
 			default:
@@ -156,9 +310,16 @@ func runCDC(cfg Config, srcDB, tgtDB *sql.DB, startFile string, startPos uint32)
 				// periodic checkpoint write using last known file/pos from syncer
 				file, pos, err := getSourceMasterStatus(srcDB)
 				if err == nil {
-					if err := WriteCheckpoint(tgtDB, cfg.CheckpointTable, keyFor(cfg), file, pos); err != nil {
+					gtidSet := streamGTIDSet
+					if gtidSet == "" {
+						gtidSet, _ = GetSourceGTIDSet(srcDB)
+					}
+					if err := WriteCheckpoint(tgtDB, cfg.CheckpointTable, keyFor(cfg), file, pos, gtidSet, "mysql"); err != nil {
 						log.Println("checkpoint write failed:", err)
+					} else {
+						globalMetrics.UpdateCheckpoint(file, pos)
 					}
+					cutover.CheckAndCutover(startFile, startPos)
 				}
 			default:
 				// no checkpoint needed
@@ -167,38 +328,147 @@ func runCDC(cfg Config, srcDB, tgtDB *sql.DB, startFile string, startPos uint32)
 	}
 }
 
-func handleRowsEvent(cfg Config, tgtDB *sql.DB, e *replication.RowsEvent, header *replication.EventHeader) error {
+// rowSizeWarning is the structured event logged when a CDC row (or a single
+// column within it) crosses Config.WarnRowBytes/WarnColumnBytes.
+type rowSizeWarning struct {
+	Schema     string `json:"schema"`
+	Table      string `json:"table"`
+	PK         string `json:"pk"`
+	Op         string `json:"op"`
+	SizeBytes  int    `json:"size_bytes"`
+	BinlogFile string `json:"binlog_file"`
+	BinlogPos  uint32 `json:"binlog_pos"`
+	EventTsMs  int64  `json:"event_ts_ms"`
+}
+
+// columnSizeBytes and rowSizeBytes compute the same len(bytes)-for-string-
+// or-[]byte-plus-fixed-size-for-everything-else estimate approxOpSize (see
+// cdc_sink.go) already uses for BatchMaxBytes, but are kept separate:
+// WarnRowBytes/WarnColumnBytes are a large-row visibility concern, not a
+// batch-size gate, and the two are free to tune or diverge independently.
+func columnSizeBytes(v interface{}) int {
+	switch t := v.(type) {
+	case []byte:
+		return len(t)
+	case string:
+		return len(t)
+	default:
+		return 8
+	}
+}
+
+func rowSizeBytes(vals []interface{}) int {
+	size := 0
+	for _, v := range vals {
+		size += columnSizeBytes(v)
+	}
+	return size
+}
+
+// warnOversizedRows logs a structured rowSizeWarning, and counts it in
+// globalMetrics, for any op in ops whose total row size or largest single
+// column exceeds cfg.WarnRowBytes/WarnColumnBytes. Either threshold set to 0
+// disables that check. file/pos identify where in the binlog this op's
+// event was read from, for correlating the warning back to the stream.
+func warnOversizedRows(cfg Config, ops []Operation, file string, pos uint32) {
+	if cfg.WarnRowBytes <= 0 && cfg.WarnColumnBytes <= 0 {
+		return
+	}
+	for _, op := range ops {
+		vals := op.After
+		if vals == nil {
+			vals = op.Before
+		}
+		rowBytes := rowSizeBytes(vals)
+		maxCol := 0
+		for _, v := range vals {
+			if n := columnSizeBytes(v); n > maxCol {
+				maxCol = n
+			}
+		}
+		oversizedRow := cfg.WarnRowBytes > 0 && rowBytes > cfg.WarnRowBytes
+		oversizedCol := cfg.WarnColumnBytes > 0 && maxCol > cfg.WarnColumnBytes
+		if !oversizedRow && !oversizedCol {
+			continue
+		}
+		globalMetrics.UpdateOversizedRowCount()
+		payload, err := json.Marshal(rowSizeWarning{
+			Schema:     op.Schema,
+			Table:      op.Table,
+			PK:         pkValuesString(op),
+			Op:         op.Kind,
+			SizeBytes:  rowBytes,
+			BinlogFile: file,
+			BinlogPos:  pos,
+			EventTsMs:  op.TsMs,
+		})
+		if err != nil {
+			log.Printf("CDC row size warning (failed to marshal): %v", err)
+			continue
+		}
+		log.Printf("CDC row size warning: %s", payload)
+	}
+}
+
+// pkValuesString renders op's primary-key tuple (see pkValues in
+// cdc_sink.go) as a single comma-joined string for structured log output.
+func pkValuesString(op Operation) string {
+	vals := pkValues(op)
+	parts := make([]string, len(vals))
+	for i, v := range vals {
+		parts[i] = fmt.Sprintf("%v", v)
+	}
+	return strings.Join(parts, ",")
+}
+
+// decodeRowsEvent turns e into one Operation per affected row, resolving
+// column names, PK columns and charset decoders the same way the row
+// applier always has, but stopping short of actually writing anywhere -
+// that's left to whichever CDCSink (see cdc_sink.go) the caller is using,
+// so the same decoded Operation can be replayed as SQL or serialized as a
+// Kafka/Debezium record.
+func decodeRowsEvent(cfg Config, tgtDB *sql.DB, tracker *SchemaTracker, e *replication.RowsEvent, header *replication.EventHeader) ([]Operation, error) {
 	if len(e.Rows) == 0 {
-		return nil
+		return nil, nil
 	}
-	
-	// Get column information from the target table (including composite PKs)
-	cols, pkCols, err := getTableColumns(tgtDB, cfg.TgtDB, cfg.TargetTable)
-	if err != nil {
-		return fmt.Errorf("failed to get table columns: %v", err)
+
+	// Prefer the schema tracker's cached column list (kept current by DDL
+	// events) over a live information_schema query on every row event. The
+	// tracked schema also carries each column's charset, which is how
+	// decoders stay in sync with `ALTER TABLE ... CONVERT TO CHARACTER SET`.
+	var cols, pkCols []string
+	var decoders []ColumnDecoder
+	if table := tracker.GetTable(cfg.SrcDB, cfg.SrcTable); table != nil {
+		cols, pkCols = table.ColumnNames(), table.PKCols
+		decoders = BuildColumnDecoders(table)
+	} else {
+		var err error
+		cols, pkCols, err = getTableColumns(tgtDB, cfg.TgtDB, cfg.TargetTable)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get table columns: %v", err)
+		}
+		// No charset metadata available yet; fall back to the old
+		// byte-sniffing heuristic rather than skipping decoding outright.
+		decoders = heuristicColumnDecoders(len(cols))
 	}
-	
+
 	// Use table's column count - truncate if row has more columns
 	numCols := len(cols)
-	
-	// Check event type to determine operation
-	eventType := header.EventType
-	
-	switch eventType {
+	tsMs := int64(header.Timestamp) * 1000
+
+	var ops []Operation
+	switch header.EventType {
 	case replication.WRITE_ROWS_EVENTv1, replication.WRITE_ROWS_EVENTv2:
-		// INSERT events
 		for _, row := range e.Rows {
 			if len(row) > numCols {
 				row = row[:numCols]
 			}
-			if err := applyRowReplace(cfg, tgtDB, cols, row); err != nil {
-				log.Println("Error applying INSERT:", err)
-				globalMetrics.UpdateError(err.Error())
-				return err
-			}
-			globalMetrics.UpdateEventCount("insert")
+			ops = append(ops, Operation{
+				Kind: OpInsert, Cols: cols, PKCols: pkCols, Decoders: decoders, After: row,
+				Schema: cfg.SrcDB, Table: cfg.SrcTable, TsMs: tsMs,
+			})
 		}
-		
+
 	case replication.UPDATE_ROWS_EVENTv1, replication.UPDATE_ROWS_EVENTv2:
 		// UPDATE events - rows come in pairs (before, after)
 		for i := 0; i < len(e.Rows); i += 2 {
@@ -213,33 +483,28 @@ func handleRowsEvent(cfg Config, tgtDB *sql.DB, e *replication.RowsEvent, header
 			if len(before) > numCols {
 				before = before[:numCols]
 			}
-			if err := applyRowUpdate(cfg, tgtDB, cols, pkCols, before, after); err != nil {
-				log.Println("Error applying UPDATE:", err)
-				globalMetrics.UpdateError(err.Error())
-				return err
-			}
-			globalMetrics.UpdateEventCount("update")
+			ops = append(ops, Operation{
+				Kind: OpUpdate, Cols: cols, PKCols: pkCols, Decoders: decoders, Before: before, After: after,
+				Schema: cfg.SrcDB, Table: cfg.SrcTable, TsMs: tsMs,
+			})
 		}
-		
+
 	case replication.DELETE_ROWS_EVENTv1, replication.DELETE_ROWS_EVENTv2:
-		// DELETE events
 		for _, row := range e.Rows {
 			if len(row) > numCols {
 				row = row[:numCols]
 			}
-			if err := applyRowDelete(cfg, tgtDB, cols, pkCols, row); err != nil {
-				log.Println("Error applying DELETE:", err)
-				globalMetrics.UpdateError(err.Error())
-				return err
-			}
-			globalMetrics.UpdateEventCount("delete")
+			ops = append(ops, Operation{
+				Kind: OpDelete, Cols: cols, PKCols: pkCols, Decoders: decoders, Before: row,
+				Schema: cfg.SrcDB, Table: cfg.SrcTable, TsMs: tsMs,
+			})
 		}
-		
+
 	default:
-		log.Printf("Unknown event type: %v", eventType)
+		log.Printf("Unknown event type: %v", header.EventType)
 	}
-	
-	return nil
+
+	return ops, nil
 }
 
 func getTableColumns(db *sql.DB, schema, table string) ([]string, []string, error) {
@@ -285,110 +550,43 @@ func getTableColumns(db *sql.DB, schema, table string) ([]string, []string, erro
 	return cols, pkCols, nil
 }
 
-func applyRowReplace(cfg Config, tgtDB *sql.DB, cols []string, row []interface{}) error {
+func applyRowReplace(cfg Config, exec sqlExecutor, cols []string, decoders []ColumnDecoder, row []interface{}) error {
 	// Use REPLACE which handles both INSERT and UPDATE
 	var colNames []string
 	for _, col := range cols {
 		colNames = append(colNames, fmt.Sprintf("`%s`", col))
 	}
-	
-	// Convert row values, ensuring strings are properly handled
-	convertedRow := make([]interface{}, len(row))
-	for i, val := range row {
-		if val == nil {
-			convertedRow[i] = nil
-		} else if bytes, ok := val.([]byte); ok {
-			// Decode UTF-32/UTF-16 bytes to UTF-8 string
-			decoded := decodeString(bytes)
-			convertedRow[i] = decoded
-		} else if str, ok := val.(string); ok {
-			// Check if this string contains UTF-32 encoded data
-			// UTF-32 has many null bytes (3 out of every 4 bytes for ASCII chars)
-			strBytes := []byte(str)
-			if len(strBytes)%4 == 0 && len(strBytes) >= 16 {
-				// Count null bytes
-				nullCount := 0
-				for _, b := range strBytes {
-					if b == 0 {
-						nullCount++
-					}
-				}
-				// If > 25% null bytes, likely UTF-32
-				if nullCount > len(strBytes)/4 {
-					decoded := decodeString(strBytes)
-					convertedRow[i] = decoded
-				} else {
-					convertedRow[i] = str
-				}
-			} else {
-				convertedRow[i] = str
-			}
-		} else {
-			convertedRow[i] = val
-		}
-	}
-	
+
+	// Decode each value with its column's charset decoder rather than
+	// sniffing the byte pattern.
+	convertedRow := batchConvertValues(decoders, row)
+
 	placeholders := strings.Repeat("?,", len(convertedRow))
 	placeholders = placeholders[:len(placeholders)-1]
 	
 	query := fmt.Sprintf("REPLACE INTO `%s`.`%s` (%s) VALUES (%s)", 
 		cfg.TgtDB, cfg.TargetTable, strings.Join(colNames, ","), placeholders)
-	_, err := tgtDB.Exec(query, convertedRow...)
+	_, err := exec.Exec(query, convertedRow...)
 	return err
 }
 
-func applyRowUpdate(cfg Config, tgtDB *sql.DB, cols []string, pkCols []string, before, after []interface{}) error {
+func applyRowUpdate(cfg Config, exec sqlExecutor, cols []string, pkCols []string, decoders []ColumnDecoder, before, after []interface{}) error {
 	// Build UPDATE statement with actual column names
 	var sets []string
 	var vals []interface{}
-	
-	// Convert values with proper charset handling - SAME LOGIC AS INSERT
-	convertValue := func(val interface{}) interface{} {
-		if val == nil {
-			return nil
-		} else if bytes, ok := val.([]byte); ok {
-			if len(bytes) == 0 {
-				return nil // Empty byte array -> NULL (prevents "Data too long" errors)
-			}
-			// Decode UTF-32/UTF-16 bytes to UTF-8 string
-			decoded := decodeString(bytes)
-			return decoded
-		} else if str, ok := val.(string); ok {
-			if str == "" {
-				return nil // Empty string -> NULL (prevents "Data too long" errors)
-			}
-			// Check if this string contains UTF-32 encoded data
-			// UTF-32 has many null bytes (3 out of every 4 bytes for ASCII chars)
-			strBytes := []byte(str)
-			if len(strBytes)%4 == 0 && len(strBytes) >= 16 {
-				// Count null bytes
-				nullCount := 0
-				for _, b := range strBytes {
-					if b == 0 {
-						nullCount++
-					}
-				}
-				// If > 25% null bytes, likely UTF-32
-				if nullCount > len(strBytes)/4 {
-					decoded := decodeString(strBytes)
-					return decoded
-				} else {
-					return str
-				}
-			} else {
-				return str
-			}
-		}
-		return val
-	}
-	
+
+	// Decode both row images with the same per-column decoders used for
+	// INSERT, so the SET and WHERE values are converted consistently.
+	convertedAfter := batchConvertValues(decoders, after)
+	convertedBefore := batchConvertValues(decoders, before)
+
 	for i, col := range cols {
-		if i < len(after) {
+		if i < len(convertedAfter) {
 			sets = append(sets, fmt.Sprintf("`%s`=?", col))
-			vals = append(vals, convertValue(after[i]))
+			vals = append(vals, convertedAfter[i])
 		}
 	}
-	
+
 	// Build WHERE clause for ALL primary key columns (supports composite PKs)
 	var whereClauses []string
 	for _, pkCol := range pkCols {
@@ -399,70 +597,33 @@ func applyRowUpdate(cfg Config, tgtDB *sql.DB, cols []string, pkCols []string, b
 				break
 			}
 		}
-		
-		if pkIdx >= 0 && pkIdx < len(before) {
+
+		if pkIdx >= 0 && pkIdx < len(convertedBefore) {
 			whereClauses = append(whereClauses, fmt.Sprintf("`%s`=?", pkCol))
-			vals = append(vals, convertValue(before[pkIdx]))
+			vals = append(vals, convertedBefore[pkIdx])
 		}
 	}
-	
+
 	// Fallback if no PK columns found
 	if len(whereClauses) == 0 {
 		whereClauses = append(whereClauses, "`"+cols[0]+"`=?")
-		vals = append(vals, convertValue(before[0]))
+		vals = append(vals, convertedBefore[0])
 	}
 	
 	query := fmt.Sprintf("UPDATE `%s`.`%s` SET %s WHERE %s", 
 		cfg.TgtDB, cfg.TargetTable, strings.Join(sets, ","), strings.Join(whereClauses, " AND "))
-	_, err := tgtDB.Exec(query, vals...)
+	_, err := exec.Exec(query, vals...)
 	return err
 }
 
-func applyRowDelete(cfg Config, tgtDB *sql.DB, cols []string, pkCols []string, row []interface{}) error {
-	// Convert values with proper charset handling - SAME LOGIC AS INSERT
-	convertValue := func(val interface{}) interface{} {
-		if val == nil {
-			return nil
-		} else if bytes, ok := val.([]byte); ok {
-			if len(bytes) == 0 {
-				return nil // Empty byte array -> NULL (prevents "Data too long" errors)
-			}
-			// Decode UTF-32/UTF-16 bytes to UTF-8 string
-			decoded := decodeString(bytes)
-			return decoded
-		} else if str, ok := val.(string); ok {
-			if str == "" {
-				return nil // Empty string -> NULL (prevents "Data too long" errors)
-			}
-			// Check if this string contains UTF-32 encoded data
-			// UTF-32 has many null bytes (3 out of every 4 bytes for ASCII chars)
-			strBytes := []byte(str)
-			if len(strBytes)%4 == 0 && len(strBytes) >= 16 {
-				// Count null bytes
-				nullCount := 0
-				for _, b := range strBytes {
-					if b == 0 {
-						nullCount++
-					}
-				}
-				// If > 25% null bytes, likely UTF-32
-				if nullCount > len(strBytes)/4 {
-					decoded := decodeString(strBytes)
-					return decoded
-				} else {
-					return str
-				}
-			} else {
-				return str
-			}
-		}
-		return val
-	}
-	
+func applyRowDelete(cfg Config, exec sqlExecutor, cols []string, pkCols []string, decoders []ColumnDecoder, row []interface{}) error {
+	// Decode with the same per-column decoders used for INSERT/UPDATE.
+	convertedRow := batchConvertValues(decoders, row)
+
 	// Build WHERE clause for ALL primary key columns (supports composite PKs)
 	var whereClauses []string
 	var pkVals []interface{}
-	
+
 	for _, pkCol := range pkCols {
 		pkIdx := -1
 		for i, col := range cols {
@@ -471,21 +632,21 @@ func applyRowDelete(cfg Config, tgtDB *sql.DB, cols []string, pkCols []string, r
 				break
 			}
 		}
-		
-		if pkIdx >= 0 && pkIdx < len(row) {
+
+		if pkIdx >= 0 && pkIdx < len(convertedRow) {
 			whereClauses = append(whereClauses, fmt.Sprintf("`%s`=?", pkCol))
-			pkVals = append(pkVals, convertValue(row[pkIdx]))
+			pkVals = append(pkVals, convertedRow[pkIdx])
 		}
 	}
-	
+
 	// Fallback if no PK columns found
 	if len(whereClauses) == 0 {
 		whereClauses = append(whereClauses, "`"+cols[0]+"`=?")
-		pkVals = append(pkVals, convertValue(row[0]))
+		pkVals = append(pkVals, convertedRow[0])
 	}
 	
 	query := fmt.Sprintf("DELETE FROM `%s`.`%s` WHERE %s", 
 		cfg.TgtDB, cfg.TargetTable, strings.Join(whereClauses, " AND "))
-	_, err := tgtDB.Exec(query, pkVals...)
+	_, err := exec.Exec(query, pkVals...)
 	return err
 }
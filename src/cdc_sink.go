@@ -0,0 +1,488 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Operation is one decoded row-level change from the binlog, carrying
+// already-charset-decoded values (see decodeRowsEvent in cdc.go) so no
+// CDCSink implementation has to know about column charsets or MySQL's wire
+// format - only how to turn an already-decoded row into SQL, a Kafka
+// record, or whatever else a future sink needs.
+type Operation struct {
+	Kind     string
+	Cols     []string
+	PKCols   []string
+	Decoders []ColumnDecoder
+	Before   []interface{} // nil for OpInsert
+	After    []interface{} // nil for OpDelete
+	Schema   string
+	Table    string
+	TsMs     int64
+}
+
+// Operation.Kind values, matching the three row-level DML operations the
+// binlog's WRITE/UPDATE/DELETE_ROWS_EVENT types decode into.
+const (
+	OpInsert = "insert"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// CDCCheckpoint is the binlog position (and, when available, GTID set) to
+// persist alongside whatever row data a CDCSink just committed, mirroring
+// the arguments WriteCheckpoint already takes.
+type CDCCheckpoint struct {
+	Table   string
+	Key     string
+	File    string
+	Pos     uint32
+	GTIDSet string
+	Flavor  string
+}
+
+// CDCSink abstracts where decoded CDC row operations are delivered, so
+// runCDC doesn't have to know whether it's writing straight to the target
+// MySQL table or producing Debezium-style records to Kafka. Implementations
+// are free to buffer Apply calls internally (as MySQLCDCSink does, to keep
+// one source transaction's DML atomic with its checkpoint) - Flush and
+// Commit exist precisely to give runCDC control over when that buffered
+// work actually lands, at the oversized-transaction split and the XIDEvent
+// boundary respectively.
+type CDCSink interface {
+	// Name identifies the sink implementation for logging.
+	Name() string
+	// Apply records one decoded row operation. Depending on the
+	// implementation, this may buffer op rather than delivering it
+	// immediately; see Flush and Commit.
+	Apply(ctx context.Context, op Operation) error
+	// Flush delivers everything buffered so far without persisting a
+	// checkpoint, for splitting an oversized in-flight source transaction
+	// or flushing ahead of DDL.
+	Flush(ctx context.Context) error
+	// Commit delivers everything buffered so far and persists cp in the
+	// same unit of work where the sink supports one, so a crash can never
+	// leave delivered rows uncheckpointed or a checkpoint advanced past
+	// rows that were never actually delivered.
+	Commit(ctx context.Context, cp CDCCheckpoint) error
+	// Pending reports how much work Apply has buffered since the last
+	// Flush/Commit, so runCDC can decide when to split an oversized
+	// transaction (against Config.BatchMaxRows/BatchMaxBytes).
+	Pending() (rows, bytes int)
+	Close() error
+}
+
+// NewCDCSink builds the CDCSink selected by cfg.CDCSinkDSN: the default
+// MySQL sink for an empty DSN, matching every existing deployment, or a
+// Kafka/Debezium producer for a kafka:// DSN. Checkpoint bookkeeping always
+// goes through tgtDB regardless of which sink is chosen - the same rule
+// TargetSink documents for full load (see sink.go) - since tgtDB is the
+// only place a resumed run knows to look for one.
+func NewCDCSink(cfg Config, tgtDB *sql.DB) (CDCSink, error) {
+	if strings.HasPrefix(cfg.CDCSinkDSN, "kafka://") {
+		return newKafkaCDCSink(strings.TrimPrefix(cfg.CDCSinkDSN, "kafka://"), tgtDB)
+	}
+	return newMySQLCDCSink(cfg, tgtDB), nil
+}
+
+// MySQLCDCSink is the tool's original CDC behavior: each Operation is
+// replayed as a REPLACE/UPDATE/DELETE against the target table. buckets
+// accumulate the DML for the source transaction currently in flight so it
+// commits to db atomically at Commit, instead of each row autocommitting
+// on its own - see txBuffer's doc comment.
+//
+// With the default Config.ApplyWorkers of 1 there is exactly one bucket and
+// behavior is unchanged from before per-key buckets existed: the whole
+// source transaction applies and commits as a single tgtDB transaction.
+// Raising ApplyWorkers hashes each op by its primary-key tuple (via
+// pkBucket) into one of N buckets, so ops for the same key always land in
+// the same bucket and stay ordered relative to each other, while ops for
+// different keys can be committed by different buckets concurrently - see
+// flushBuckets. That concurrency is real (each bucket applies through its
+// own tgtDB connection and transaction) but it costs something: with more
+// than one bucket, the source transaction's DML is no longer atomic as one
+// unit - one bucket can commit while a sibling bucket fails. flushBuckets
+// still applies a completion barrier at every XIDEvent (Commit never
+// returns, and the checkpoint never advances, until every bucket's
+// transaction has either committed or the whole call has failed), so a
+// checkpoint is never persisted ahead of rows that didn't make it - but
+// operators who need the original all-or-nothing guarantee for the entire
+// source transaction should leave ApplyWorkers at 1.
+type MySQLCDCSink struct {
+	cfg     Config
+	db      *sql.DB
+	buckets []*txBuffer
+}
+
+// newMySQLCDCSink allocates cfg.ApplyWorkers buckets (at least 1), each
+// pre-sized to cfg.ApplyQueueDepth ops to avoid reallocation churn while a
+// source transaction is buffering.
+func newMySQLCDCSink(cfg Config, db *sql.DB) *MySQLCDCSink {
+	workers := cfg.ApplyWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	buckets := make([]*txBuffer, workers)
+	for i := range buckets {
+		buckets[i] = newTxBuffer(cfg.ApplyQueueDepth)
+	}
+	return &MySQLCDCSink{cfg: cfg, db: db, buckets: buckets}
+}
+
+func (s *MySQLCDCSink) Name() string { return "mysql" }
+
+func (s *MySQLCDCSink) bucketFor(op Operation) *txBuffer {
+	return s.buckets[pkBucket(op, len(s.buckets))]
+}
+
+func (s *MySQLCDCSink) Apply(ctx context.Context, op Operation) error {
+	switch op.Kind {
+	case OpInsert:
+		s.bucketFor(op).add(approxOpSize(op.After), func(exec sqlExecutor) error {
+			if err := applyRowReplace(s.cfg, exec, op.Cols, op.Decoders, op.After); err != nil {
+				globalMetrics.UpdateError(err.Error())
+				return fmt.Errorf("apply INSERT: %v", err)
+			}
+			globalMetrics.UpdateEventCount("insert")
+			return nil
+		})
+	case OpUpdate:
+		s.bucketFor(op).add(approxOpSize(op.After), func(exec sqlExecutor) error {
+			if err := applyRowUpdate(s.cfg, exec, op.Cols, op.PKCols, op.Decoders, op.Before, op.After); err != nil {
+				globalMetrics.UpdateError(err.Error())
+				return fmt.Errorf("apply UPDATE: %v", err)
+			}
+			globalMetrics.UpdateEventCount("update")
+			return nil
+		})
+	case OpDelete:
+		s.bucketFor(op).add(approxOpSize(op.Before), func(exec sqlExecutor) error {
+			if err := applyRowDelete(s.cfg, exec, op.Cols, op.PKCols, op.Decoders, op.Before); err != nil {
+				globalMetrics.UpdateError(err.Error())
+				return fmt.Errorf("apply DELETE: %v", err)
+			}
+			globalMetrics.UpdateEventCount("delete")
+			return nil
+		})
+	default:
+		return fmt.Errorf("unknown operation kind %q", op.Kind)
+	}
+	return nil
+}
+
+func (s *MySQLCDCSink) Flush(ctx context.Context) error {
+	if err := flushBuckets(s.db, s.buckets, nil); err != nil {
+		return err
+	}
+	s.resetBuckets()
+	return nil
+}
+
+func (s *MySQLCDCSink) Commit(ctx context.Context, cp CDCCheckpoint) error {
+	err := flushBuckets(s.db, s.buckets, func(exec sqlExecutor) error {
+		return WriteCheckpoint(exec, cp.Table, cp.Key, cp.File, cp.Pos, cp.GTIDSet, cp.Flavor)
+	})
+	s.resetBuckets()
+	// flushBuckets only returns nil once the checkpoint's own transaction (or
+	// every bucket's, for the multi-bucket case) has actually committed, so
+	// only now is it safe to advance the metric - doing it from inside
+	// WriteCheckpoint itself would report a checkpoint that a failed
+	// tx.Commit immediately after rolled back.
+	if err == nil {
+		globalMetrics.UpdateCheckpoint(cp.File, cp.Pos)
+	}
+	return err
+}
+
+func (s *MySQLCDCSink) resetBuckets() {
+	for i := range s.buckets {
+		s.buckets[i] = newTxBuffer(s.cfg.ApplyQueueDepth)
+	}
+}
+
+func (s *MySQLCDCSink) Pending() (rows, bytes int) {
+	for _, b := range s.buckets {
+		rows += b.rows
+		bytes += b.bytes
+	}
+	return
+}
+
+// Close is a no-op: db is owned by the caller, same as MySQLSink.
+func (s *MySQLCDCSink) Close() error { return nil }
+
+// pkBucket hashes op's primary-key tuple to a bucket index in [0,
+// numBuckets), so repeated changes to the same key are always ordered
+// relative to each other (same bucket, applied in Apply order within a
+// flush) while different keys can spread across buckets for concurrent
+// commit. With a single bucket (the default) this always returns 0.
+func pkBucket(op Operation, numBuckets int) int {
+	if numBuckets <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	for _, v := range pkValues(op) {
+		fmt.Fprintf(h, "%v|", v)
+	}
+	return int(h.Sum32() % uint32(numBuckets))
+}
+
+// pkValues returns op's primary-key column values in PKCols order, reading
+// them from Before (the row's identity prior to the change) and falling
+// back to After for inserts, which have no Before image.
+func pkValues(op Operation) []interface{} {
+	row := op.Before
+	if row == nil {
+		row = op.After
+	}
+	vals := make([]interface{}, 0, len(op.PKCols))
+	for _, pk := range op.PKCols {
+		for i, c := range op.Cols {
+			if c == pk && i < len(row) {
+				vals = append(vals, row[i])
+				break
+			}
+		}
+	}
+	return vals
+}
+
+// txBuffer accumulates the target-side DML for one source transaction so it
+// can be applied inside a single tgtDB transaction at that transaction's
+// commit, instead of every row autocommitting on its own. Each op closes
+// over its already-decoded values; flushTxBuffer is the only thing that
+// ever runs them, always against a *sql.Tx.
+type txBuffer struct {
+	ops   []func(exec sqlExecutor) error
+	rows  int
+	bytes int
+}
+
+// newTxBuffer allocates a txBuffer, pre-sizing its ops slice to capHint when
+// positive to absorb a bucket's expected load without reallocating as a
+// source transaction buffers.
+func newTxBuffer(capHint int) *txBuffer {
+	if capHint <= 0 {
+		return &txBuffer{}
+	}
+	return &txBuffer{ops: make([]func(exec sqlExecutor) error, 0, capHint)}
+}
+
+func (b *txBuffer) add(size int, op func(exec sqlExecutor) error) {
+	b.ops = append(b.ops, op)
+	b.rows++
+	b.bytes += size
+}
+
+// approxOpSize estimates the on-wire size of a row's decoded values, just
+// well enough to gate BatchMaxBytes; it is not an exact byte count.
+func approxOpSize(vals []interface{}) int {
+	size := 0
+	for _, v := range vals {
+		switch t := v.(type) {
+		case []byte:
+			size += len(t)
+		case string:
+			size += len(t)
+		default:
+			size += 8
+		}
+	}
+	return size
+}
+
+// flushTxBuffer applies every buffered op inside one tgtDB transaction and,
+// when checkpoint is non-nil, persists the checkpoint in that same
+// transaction, so a crash can never leave applied rows uncheckpointed (or a
+// checkpoint advanced past rows that were never actually committed). A nil
+// checkpoint is used for the oversized-transaction and pre-DDL splits,
+// which must not advance the checkpoint before the source transaction
+// they're part of has actually reached XID.
+func flushTxBuffer(tgtDB *sql.DB, buf *txBuffer, checkpoint func(exec sqlExecutor) error) error {
+	if len(buf.ops) == 0 && checkpoint == nil {
+		return nil
+	}
+	tx, err := tgtDB.Begin()
+	if err != nil {
+		return fmt.Errorf("begin apply transaction: %v", err)
+	}
+	for _, op := range buf.ops {
+		if err := op(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if checkpoint != nil {
+		if err := checkpoint(tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("write checkpoint in apply transaction: %v", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// flushBuckets is flushTxBuffer generalized to N per-key buckets. With a
+// single bucket it's identical to calling flushTxBuffer directly: ops and
+// checkpoint commit together in the same transaction. With more than one
+// bucket, each non-empty bucket applies through its own flushTxBuffer call
+// (its own connection and transaction) concurrently; flushBuckets is a
+// barrier that waits for every one of them before returning, and only once
+// all have succeeded does it persist checkpoint, in one more short
+// transaction of its own. If any bucket fails, checkpoint is never written,
+// matching flushTxBuffer's own all-or-nothing rule for the single-bucket
+// case - the difference is that with multiple buckets a sibling bucket may
+// already have committed, so the tracked table can end up partially applied
+// for that source transaction. See MySQLCDCSink's doc comment for why that
+// tradeoff is opt-in (Config.ApplyWorkers > 1) rather than the default.
+func flushBuckets(tgtDB *sql.DB, buckets []*txBuffer, checkpoint func(exec sqlExecutor) error) error {
+	if len(buckets) == 1 {
+		return flushTxBuffer(tgtDB, buckets[0], checkpoint)
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, len(buckets))
+	for i, b := range buckets {
+		if len(b.ops) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, b *txBuffer) {
+			defer wg.Done()
+			errs[i] = flushTxBuffer(tgtDB, b, nil)
+		}(i, b)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	if checkpoint == nil {
+		return nil
+	}
+	return flushTxBuffer(tgtDB, newTxBuffer(0), checkpoint)
+}
+
+// KafkaCDCSink publishes each Operation as a Debezium-style JSON envelope,
+// turning the tool into a general CDC producer for downstream consumers
+// instead of only a MySQL-to-MySQL replicator. Checkpoint bookkeeping still
+// lives in tgtDB (see NewCDCSink) since Kafka itself has no notion of a
+// replication checkpoint to resume from.
+type KafkaCDCSink struct {
+	writer  *kafka.Writer
+	tgtDB   *sql.DB
+	pending []kafka.Message
+	rows    int
+	bytes   int
+}
+
+// newKafkaCDCSink parses a "broker1:9092,broker2:9092/topic" address (the
+// part of a kafka:// DSN after the scheme) and opens a writer for it.
+func newKafkaCDCSink(addrAndTopic string, tgtDB *sql.DB) (*KafkaCDCSink, error) {
+	parts := strings.SplitN(addrAndTopic, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("CDC_SINK_DSN must be kafka://broker1:9092,broker2:9092/topic, got %q", "kafka://"+addrAndTopic)
+	}
+	brokers := strings.Split(parts[0], ",")
+	return &KafkaCDCSink{
+		writer: &kafka.Writer{
+			Addr:     kafka.TCP(brokers...),
+			Topic:    parts[1],
+			Balancer: &kafka.LeastBytes{},
+		},
+		tgtDB: tgtDB,
+	}, nil
+}
+
+func (s *KafkaCDCSink) Name() string { return "kafka" }
+
+// debeziumEnvelope mirrors the subset of Debezium's change-event format
+// this tool can populate from binlog data: op code, before/after row
+// images keyed by column name, and a source block identifying where the
+// change came from.
+type debeziumEnvelope struct {
+	Op     string                 `json:"op"`
+	Before map[string]interface{} `json:"before,omitempty"`
+	After  map[string]interface{} `json:"after,omitempty"`
+	Source debeziumSource         `json:"source"`
+	TsMs   int64                  `json:"ts_ms"`
+}
+
+type debeziumSource struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	TsMs   int64  `json:"ts_ms"`
+}
+
+// debeziumOpCodes maps Operation.Kind to Debezium's single-letter op code:
+// c(reate), u(pdate), d(elete).
+var debeziumOpCodes = map[string]string{OpInsert: "c", OpUpdate: "u", OpDelete: "d"}
+
+func (s *KafkaCDCSink) Apply(ctx context.Context, op Operation) error {
+	payload, err := json.Marshal(debeziumEnvelope{
+		Op:     debeziumOpCodes[op.Kind],
+		Before: rowToMap(op.Cols, op.Decoders, op.Before),
+		After:  rowToMap(op.Cols, op.Decoders, op.After),
+		Source: debeziumSource{Schema: op.Schema, Table: op.Table, TsMs: op.TsMs},
+		TsMs:   op.TsMs,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal debezium envelope: %v", err)
+	}
+	s.pending = append(s.pending, kafka.Message{Value: payload})
+	s.rows++
+	s.bytes += len(payload)
+	globalMetrics.UpdateEventCount(op.Kind)
+	return nil
+}
+
+// rowToMap decodes vals with decoders and zips the result against cols,
+// returning nil (not an empty map) when vals is nil, so json.Marshal omits
+// "before"/"after" for an insert/delete the way Debezium's own format does.
+func rowToMap(cols []string, decoders []ColumnDecoder, vals []interface{}) map[string]interface{} {
+	if vals == nil {
+		return nil
+	}
+	converted := batchConvertValues(decoders, vals)
+	m := make(map[string]interface{}, len(cols))
+	for i, c := range cols {
+		if i < len(converted) {
+			m[c] = converted[i]
+		}
+	}
+	return m
+}
+
+func (s *KafkaCDCSink) Flush(ctx context.Context) error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	if err := s.writer.WriteMessages(ctx, s.pending...); err != nil {
+		return fmt.Errorf("publish to kafka: %v", err)
+	}
+	s.pending = nil
+	s.rows, s.bytes = 0, 0
+	return nil
+}
+
+func (s *KafkaCDCSink) Commit(ctx context.Context, cp CDCCheckpoint) error {
+	if err := s.Flush(ctx); err != nil {
+		return err
+	}
+	if err := WriteCheckpoint(s.tgtDB, cp.Table, cp.Key, cp.File, cp.Pos, cp.GTIDSet, cp.Flavor); err != nil {
+		return err
+	}
+	globalMetrics.UpdateCheckpoint(cp.File, cp.Pos)
+	return nil
+}
+
+func (s *KafkaCDCSink) Pending() (rows, bytes int) { return s.rows, s.bytes }
+
+func (s *KafkaCDCSink) Close() error { return s.writer.Close() }
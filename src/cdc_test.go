@@ -153,12 +153,12 @@ func TestKeyFor(t *testing.T) {
 		SrcDB:    "source_db",
 		SrcTable: "source_table",
 	}
-	
+
 	result := keyFor(cfg)
-	
-	// Should contain DSN, database, and table
-	if !strings.Contains(result, cfg.SrcDSN) {
-		t.Errorf("keyFor should contain DSN")
+
+	// Should contain the parsed host:port, database, and table
+	if !strings.Contains(result, "localhost:3306") {
+		t.Errorf("keyFor should contain parsed host:port, got %q", result)
 	}
 	if !strings.Contains(result, cfg.SrcDB) {
 		t.Errorf("keyFor should contain source database")
@@ -0,0 +1,506 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// DiscoverChunkKey finds the best unique index to iterate the table by, in
+// the style of gh-ost's chunking strategy: prefer the PRIMARY KEY, otherwise
+// the shortest unique index whose columns are all NOT NULL. Returns nil when
+// no such index exists, meaning the caller must fall back to an unordered
+// streaming load.
+func DiscoverChunkKey(db *sql.DB, schema, table string) ([]string, error) {
+	q := `
+SELECT s.INDEX_NAME, s.COLUMN_NAME
+FROM information_schema.STATISTICS s
+WHERE s.TABLE_SCHEMA = ? AND s.TABLE_NAME = ? AND s.NON_UNIQUE = 0
+ORDER BY s.INDEX_NAME, s.SEQ_IN_INDEX`
+	rows, err := db.Query(q, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := make(map[string][]string)
+	var order []string
+	for rows.Next() {
+		var idxName, colName string
+		if err := rows.Scan(&idxName, &colName); err != nil {
+			return nil, err
+		}
+		if _, ok := indexes[idxName]; !ok {
+			order = append(order, idxName)
+		}
+		indexes[idxName] = append(indexes[idxName], colName)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if cols, ok := indexes["PRIMARY"]; ok {
+		return cols, nil
+	}
+
+	nullable, err := nullableColumns(db, schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var best []string
+	for _, idxName := range order {
+		cols := indexes[idxName]
+		if anyNullable(cols, nullable) {
+			continue
+		}
+		if best == nil || len(cols) < len(best) {
+			best = cols
+		}
+	}
+	return best, nil
+}
+
+func nullableColumns(db *sql.DB, schema, table string) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT COLUMN_NAME, IS_NULLABLE FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[string]bool)
+	for rows.Next() {
+		var col, isNullable string
+		if err := rows.Scan(&col, &isNullable); err != nil {
+			return nil, err
+		}
+		res[col] = isNullable == "YES"
+	}
+	return res, rows.Err()
+}
+
+func anyNullable(cols []string, nullable map[string]bool) bool {
+	for _, c := range cols {
+		if nullable[c] {
+			return true
+		}
+	}
+	return false
+}
+
+// chunkedKeyLoad copies a table using keyCols as the chunk boundary, the
+// gh-ost way: pre-compute roughly-equal key ranges with SampleKeyRanges,
+// then copy them in parallel across cfg.ParallelWorkers workers, the same
+// fan-out shape runFullLoad uses for single-integer-PK ranges. This works
+// for composite and non-integer unique keys, unlike the BIGINT range
+// splitter used for single-integer PKs.
+func chunkedKeyLoad(ctx context.Context, cfg Config, srcDB, tgtDB *sql.DB, sink TargetSink, keyCols []string, throttler *Throttler) error {
+	progressKey := keyFor(cfg)
+	numWorkers := cfg.ParallelWorkers
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+
+	ranges, err := SampleKeyRanges(srcDB, cfg.SrcDB, cfg.SrcTable, keyCols, numWorkers)
+	if err != nil {
+		return err
+	}
+	if len(ranges) == 0 {
+		log.Println("Key-chunked load: table is empty, nothing to copy")
+		return nil
+	}
+
+	done, err := GetDoneKeyChunks(tgtDB, progressKey)
+	if err != nil {
+		return err
+	}
+	doneSet := make(map[string]bool, len(done))
+	for _, d := range done {
+		doneSet[d[0]+"|"+d[1]] = true
+	}
+
+	type keyRangeTask struct {
+		seq       int64
+		low, high []string
+	}
+	var tasks []keyRangeTask
+	for i, r := range ranges {
+		lowJSON, _ := json.Marshal(r[0])
+		highJSON, _ := json.Marshal(r[1])
+		if doneSet[string(lowJSON)+"|"+string(highJSON)] {
+			continue
+		}
+		tasks = append(tasks, keyRangeTask{seq: int64(i), low: r[0], high: r[1]})
+	}
+	if len(tasks) == 0 {
+		log.Println("All key-chunked ranges already done")
+		return nil
+	}
+
+	log.Printf("Chunking full load by unique key %v into %d range(s) across %d worker(s), %d already done",
+		keyCols, len(ranges), numWorkers, len(ranges)-len(tasks))
+
+	taskCh := make(chan keyRangeTask, len(tasks))
+	errCh := make(chan error, numWorkers)
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			for task := range taskCh {
+				if ctx.Err() != nil {
+					return
+				}
+				if err := throttler.Wait(); err != nil {
+					select {
+					case errCh <- err:
+					default:
+					}
+					return
+				}
+				if _, err := copyKeyChunk(cfg, srcDB, sink, keyCols, task.low, task.high); err != nil {
+					select {
+					case errCh <- fmt.Errorf("worker %d: range %v-%v: %v", workerID, task.low, task.high, err):
+					default:
+					}
+					return
+				}
+				lowJSON, _ := json.Marshal(task.low)
+				highJSON, _ := json.Marshal(task.high)
+				if err := MarkKeyChunkDone(tgtDB, progressKey, task.seq, string(lowJSON), string(highJSON)); err != nil {
+					select {
+					case errCh <- err:
+					default:
+					}
+					return
+				}
+			}
+		}(w)
+	}
+	for _, task := range tasks {
+		taskCh <- task
+	}
+	close(taskCh)
+	wg.Wait()
+
+	select {
+	case err := <-errCh:
+		return err
+	default:
+	}
+	if ctx.Err() != nil {
+		return ErrShutdown
+	}
+
+	log.Printf("Key-based chunked load complete: %d range(s)", len(ranges))
+	return nil
+}
+
+// SampleKeyRanges partitions the table into up to numChunks roughly-equal
+// key ranges for parallel backfill workers — the composite/non-integer-key
+// analogue of GetMinMax+buildRanges, which only works for a single integer
+// PK. When MySQL 8.0 has collected a persistent optimizer histogram
+// (ANALYZE TABLE ... UPDATE HISTOGRAM) for the leading key column, its
+// bucket boundaries are used directly, avoiding extra scans; otherwise
+// boundaries are found by equi-depth sampling via repeated ORDER BY/LIMIT/
+// OFFSET probes, the same technique nextKeyChunkUpper uses for a single
+// chunk. Returns nil ranges when the table is empty.
+func SampleKeyRanges(db *sql.DB, schema, table string, keyCols []string, numChunks int) ([][2][]string, error) {
+	if numChunks < 1 {
+		numChunks = 1
+	}
+
+	var total int64
+	if err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM `%s`.`%s`", schema, table)).Scan(&total); err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return nil, nil
+	}
+	if int64(numChunks) > total {
+		numChunks = int(total)
+	}
+
+	var boundaries [][]string
+	if len(keyCols) == 1 {
+		if hb, err := histogramKeyBoundaries(db, schema, table, keyCols[0], numChunks); err == nil && len(hb) > 0 {
+			for _, v := range hb {
+				boundaries = append(boundaries, []string{v})
+			}
+		}
+	}
+	if boundaries == nil {
+		step := total / int64(numChunks)
+		if step < 1 {
+			step = 1
+		}
+		var low []string
+		for i := 0; i < numChunks-1; i++ {
+			high, ok, err := nextKeyChunkUpper(db, schema, table, keyCols, low, int(step))
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			boundaries = append(boundaries, high)
+			low = high
+		}
+	}
+
+	ranges := make([][2][]string, 0, len(boundaries)+1)
+	var low []string
+	for _, high := range boundaries {
+		ranges = append(ranges, [2][]string{low, high})
+		low = high
+	}
+	ranges = append(ranges, [2][]string{low, nil})
+	return ranges, nil
+}
+
+// histogramKeyBoundaries reads MySQL 8.0's persistent optimizer histogram
+// for col, if one has been collected, and returns up to numChunks-1
+// bucket-boundary values spread evenly across it. Returns an error (and the
+// caller falls back to OFFSET sampling) when no histogram exists or it
+// can't be parsed.
+func histogramKeyBoundaries(db *sql.DB, schema, table, col string, numChunks int) ([]string, error) {
+	var histogramJSON sql.RawBytes
+	err := db.QueryRow(
+		`SELECT HISTOGRAM FROM information_schema.COLUMN_STATISTICS WHERE SCHEMA_NAME = ? AND TABLE_NAME = ? AND COLUMN_NAME = ?`,
+		schema, table, col,
+	).Scan(&histogramJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Buckets [][]interface{} `json:"buckets"`
+	}
+	if err := json.Unmarshal(histogramJSON, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Buckets) == 0 {
+		return nil, fmt.Errorf("empty histogram for %s.%s.%s", schema, table, col)
+	}
+
+	// Each bucket is [value, cumulative_frequency] for a "singleton"
+	// histogram, or [min, max, cumulative_frequency, num_distinct] for an
+	// "equi-height" one; either way the boundary value is the element right
+	// before the frequency, i.e. index 0 or index 1 respectively.
+	valueAt := func(bucket []interface{}) (string, error) {
+		switch len(bucket) {
+		case 2:
+			return fmt.Sprintf("%v", bucket[0]), nil
+		case 4:
+			return fmt.Sprintf("%v", bucket[1]), nil
+		default:
+			return "", fmt.Errorf("malformed histogram bucket")
+		}
+	}
+
+	nBuckets := len(doc.Buckets)
+	if numChunks-1 >= nBuckets {
+		all := make([]string, 0, nBuckets)
+		for _, b := range doc.Buckets {
+			v, err := valueAt(b)
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, v)
+		}
+		return all, nil
+	}
+
+	boundaries := make([]string, 0, numChunks-1)
+	step := float64(nBuckets) / float64(numChunks)
+	for i := 1; i < numChunks; i++ {
+		idx := int(float64(i)*step) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= nBuckets {
+			idx = nBuckets - 1
+		}
+		v, err := valueAt(doc.Buckets[idx])
+		if err != nil {
+			return nil, err
+		}
+		boundaries = append(boundaries, v)
+	}
+	return boundaries, nil
+}
+
+// nextKeyChunkUpper returns the key tuple of the row chunkSize rows after
+// low (exclusive), i.e. the upper bound of the next chunk. ok is false when
+// fewer than chunkSize rows remain, meaning the caller should copy
+// everything after low with no upper bound.
+func nextKeyChunkUpper(db *sql.DB, schema, table string, keyCols []string, low []string, chunkSize int) ([]string, bool, error) {
+	where, args := chunkWhereClause(keyCols, low, nil)
+	q := fmt.Sprintf("SELECT %s FROM `%s`.`%s`%s ORDER BY %s LIMIT 1 OFFSET %d",
+		tupleColumnList(keyCols), schema, table, where, orderByClause(keyCols), chunkSize-1)
+
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, false, rows.Err()
+	}
+	values := make([]sql.RawBytes, len(keyCols))
+	scanArgs := make([]interface{}, len(values))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	if err := rows.Scan(scanArgs...); err != nil {
+		return nil, false, err
+	}
+	res := make([]string, len(values))
+	for i, v := range values {
+		res[i] = string(v)
+	}
+	return res, true, nil
+}
+
+// copyKeyChunk copies every row with a key tuple in (low, high] (or
+// everything after low when high is nil) from source to target, returning
+// the number of rows copied. It pages through the range cfg.BatchSize rows
+// at a time via keyset pagination (the same approach loadRange uses for
+// single-integer-PK ranges) rather than materializing the whole range in
+// one query: SampleKeyRanges only produces cfg.ParallelWorkers ranges, so
+// each one can be millions of rows on the large tables this is meant for,
+// and buffering that in a single batchRows slice would OOM.
+func copyKeyChunk(cfg Config, srcDB *sql.DB, sink TargetSink, keyCols []string, low, high []string) (int, error) {
+	batchSize := cfg.BatchSize
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	total := 0
+	cursor := low
+	for {
+		where, args := chunkWhereClause(keyCols, cursor, high)
+		q := fmt.Sprintf("SELECT * FROM `%s`.`%s`%s ORDER BY %s LIMIT %d", cfg.SrcDB, cfg.SrcTable, where, orderByClause(keyCols), batchSize)
+
+		rows, err := srcDB.Query(q, args...)
+		if err != nil {
+			return total, err
+		}
+
+		cols, err := rows.Columns()
+		if err != nil {
+			rows.Close()
+			return total, err
+		}
+
+		values := make([]sql.RawBytes, len(cols))
+		scanArgs := make([]interface{}, len(values))
+		for i := range values {
+			scanArgs[i] = &values[i]
+		}
+
+		var batchRows [][]interface{}
+		for rows.Next() {
+			if err := rows.Scan(scanArgs...); err != nil {
+				rows.Close()
+				return total, err
+			}
+			args := make([]interface{}, len(values))
+			for i, v := range values {
+				if v == nil {
+					args[i] = nil
+				} else {
+					args[i] = string(v)
+				}
+			}
+			batchRows = append(batchRows, args)
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return total, err
+		}
+		rows.Close()
+
+		if len(batchRows) == 0 {
+			break
+		}
+
+		if err := sink.WriteBatch(cfg, cols, batchRows); err != nil {
+			return total, err
+		}
+		total += len(batchRows)
+
+		// Advance the cursor to the key tuple of this page's last row,
+		// rather than reading it back from the target: the target may not
+		// even be a queryable SQL database (e.g. a file sink), and the
+		// source page is already ordered by keyCols, so its own last row
+		// is the correct next cursor.
+		lastRow := batchRows[len(batchRows)-1]
+		next := make([]string, len(keyCols))
+		for i, kc := range keyCols {
+			idx := -1
+			for j, c := range cols {
+				if c == kc {
+					idx = j
+					break
+				}
+			}
+			if idx < 0 {
+				return total, fmt.Errorf("key column %q not found in result columns", kc)
+			}
+			next[i] = fmt.Sprintf("%v", lastRow[idx])
+		}
+		cursor = next
+	}
+	return total, nil
+}
+
+// chunkWhereClause builds `WHERE (keyCols...) > (low...) AND (keyCols...) <= (high...)`,
+// using plain column comparisons instead of row constructors for a
+// single-column key, omitting either side when its bound is nil.
+func chunkWhereClause(keyCols []string, low, high []string) (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+	if low != nil {
+		clauses = append(clauses, tupleCompare(keyCols, ">"))
+		for _, v := range low {
+			args = append(args, v)
+		}
+	}
+	if high != nil {
+		clauses = append(clauses, tupleCompare(keyCols, "<="))
+		for _, v := range high {
+			args = append(args, v)
+		}
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return " WHERE " + join(clauses, " AND "), args
+}
+
+func tupleCompare(keyCols []string, op string) string {
+	if len(keyCols) == 1 {
+		return fmt.Sprintf("%s %s ?", QuoteIdent(keyCols[0]), op)
+	}
+	placeholders := make([]string, len(keyCols))
+	for i := range placeholders {
+		placeholders[i] = "?"
+	}
+	return fmt.Sprintf("(%s) %s (%s)", tupleColumnList(keyCols), op, join(placeholders, ", "))
+}
+
+func tupleColumnList(keyCols []string) string {
+	quoted := make([]string, len(keyCols))
+	for i, c := range keyCols {
+		quoted[i] = QuoteIdent(c)
+	}
+	return join(quoted, ", ")
+}
+
+func orderByClause(keyCols []string) string {
+	return tupleColumnList(keyCols)
+}
@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestTupleCompare_SingleColumn(t *testing.T) {
+	got := tupleCompare([]string{"id"}, ">")
+	want := "`id` > ?"
+	if got != want {
+		t.Errorf("tupleCompare single column: got %q, want %q", got, want)
+	}
+}
+
+func TestTupleCompare_Composite(t *testing.T) {
+	got := tupleCompare([]string{"tenant_id", "id"}, "<=")
+	want := "(`tenant_id`, `id`) <= (?, ?)"
+	if got != want {
+		t.Errorf("tupleCompare composite: got %q, want %q", got, want)
+	}
+}
+
+func TestChunkWhereClause_NoBounds(t *testing.T) {
+	where, args := chunkWhereClause([]string{"id"}, nil, nil)
+	if where != "" || len(args) != 0 {
+		t.Errorf("expected empty where/args, got %q, %v", where, args)
+	}
+}
+
+func TestChunkWhereClause_LowAndHigh(t *testing.T) {
+	where, args := chunkWhereClause([]string{"id"}, []string{"10"}, []string{"20"})
+	wantWhere := " WHERE `id` > ? AND `id` <= ?"
+	if where != wantWhere {
+		t.Errorf("where: got %q, want %q", where, wantWhere)
+	}
+	if len(args) != 2 || args[0] != "10" || args[1] != "20" {
+		t.Errorf("args: got %v", args)
+	}
+}
+
+func TestOrderByClause_Composite(t *testing.T) {
+	got := orderByClause([]string{"a", "b"})
+	want := "`a`, `b`"
+	if got != want {
+		t.Errorf("orderByClause: got %q, want %q", got, want)
+	}
+}
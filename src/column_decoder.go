@@ -0,0 +1,154 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/unicode/utf32"
+)
+
+// ColumnDecoder pairs a tracked column with the decoder for its MySQL
+// character set, resolved once from schema metadata instead of sniffed per
+// value. A nil Decoder means passthrough: the column is already UTF-8
+// (utf8/utf8mb4), binary, or an unrecognized charset, so no conversion is
+// applied. Heuristic is set instead of Decoder when the caller has no
+// charset metadata at all, falling back to the old byte-sniffing decodeString.
+type ColumnDecoder struct {
+	Name      string
+	Charset   string
+	Decoder   *encoding.Decoder
+	Heuristic bool
+}
+
+// Decode converts b from the column's declared character set to UTF-8. A
+// decode error falls back to the raw bytes rather than failing the row,
+// matching the tool's existing best-effort handling of unexpected data.
+func (d ColumnDecoder) Decode(b []byte) string {
+	if d.Heuristic {
+		return decodeString(b)
+	}
+	if d.Decoder == nil {
+		return string(b)
+	}
+	out, err := d.Decoder.Bytes(b)
+	if err != nil {
+		return string(b)
+	}
+	return string(out)
+}
+
+// heuristicColumnDecoders builds n decoders that fall back to decodeString's
+// UTF-32/UTF-16 byte sniffing, for callers that have a column count but no
+// charset metadata to resolve real ColumnDecoders from (the schema tracker's
+// cache miss path in decodeRowsEvent).
+func heuristicColumnDecoders(n int) []ColumnDecoder {
+	decoders := make([]ColumnDecoder, n)
+	for i := range decoders {
+		decoders[i] = ColumnDecoder{Heuristic: true}
+	}
+	return decoders
+}
+
+// BuildColumnDecoders resolves a ColumnDecoder for every column of schema,
+// in ordinal order, from its already-known per-column charset metadata
+// rather than re-querying information_schema on every row event. A column
+// reporting a charset outside knownCharsets is logged, since it otherwise
+// decodes as silent (and possibly wrong) UTF-8 passthrough with no signal
+// that it was never actually mapped to a decoder.
+func BuildColumnDecoders(schema *TableSchema) []ColumnDecoder {
+	decoders := make([]ColumnDecoder, len(schema.Columns))
+	for i, c := range schema.Columns {
+		if !knownCharsets[strings.ToLower(c.Charset)] {
+			log.Printf("Warning: column %s.%s.%s has unrecognized charset %q, decoding as UTF-8 passthrough", schema.Schema, schema.Table, c.Name, c.Charset)
+		}
+		decoders[i] = ColumnDecoder{Name: c.Name, Charset: c.Charset, Decoder: decoderForCharset(c.Charset)}
+	}
+	return decoders
+}
+
+// knownCharsets lists every MySQL CHARACTER_SET_NAME decoderForCharset
+// explicitly recognizes, whether it maps to a real decoder or is treated as
+// already-UTF-8-compatible passthrough, so BuildColumnDecoders can tell that
+// apart from a charset it's simply never seen before.
+var knownCharsets = map[string]bool{
+	"": true, "utf8": true, "utf8mb4": true, "binary": true, "ascii": true,
+	"latin1": true, "ucs2": true, "utf16": true, "utf16le": true, "utf32": true,
+	"gbk": true, "gb18030": true, "big5": true, "sjis": true, "euckr": true,
+}
+
+// decoderForCharset maps a MySQL CHARACTER_SET_NAME to the matching
+// golang.org/x/text/encoding decoder. Returns nil (passthrough) for
+// utf8/utf8mb4/binary/ascii columns and any charset this tool doesn't
+// special-case, since go-mysql already hands those row values back as
+// UTF-8-compatible bytes.
+func decoderForCharset(charset string) *encoding.Decoder {
+	switch strings.ToLower(charset) {
+	case "", "utf8", "utf8mb4", "binary", "ascii":
+		return nil
+	case "latin1":
+		return charmap.Windows1252.NewDecoder()
+	case "ucs2":
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder()
+	case "utf16":
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder()
+	case "utf16le":
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder()
+	case "utf32":
+		return utf32.UTF32(utf32.BigEndian, utf32.IgnoreBOM).NewDecoder()
+	case "gbk":
+		return simplifiedchinese.GBK.NewDecoder()
+	case "gb18030":
+		return simplifiedchinese.GB18030.NewDecoder()
+	case "big5":
+		return traditionalchinese.Big5.NewDecoder()
+	case "sjis":
+		return japanese.ShiftJIS.NewDecoder()
+	case "euckr":
+		return korean.EUCKR.NewDecoder()
+	default:
+		return nil
+	}
+}
+
+// batchConvertValues converts a row's raw binlog values (bytes or strings)
+// to their decoded UTF-8 form, dispatching each one to its column's decoder
+// instead of sniffing the byte pattern. decoders is expected to be parallel
+// to values, as produced by BuildColumnDecoders; values past the end of
+// decoders pass through undecoded. NULLs and non-string/byte values are
+// returned unchanged; an empty byte/string value becomes NULL, since this
+// tool has historically seen those as padding artifacts rather than real
+// empty strings.
+func batchConvertValues(decoders []ColumnDecoder, values []interface{}) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		var raw []byte
+		switch t := v.(type) {
+		case []byte:
+			raw = t
+		case string:
+			raw = []byte(t)
+		default:
+			out[i] = v
+			continue
+		}
+		if len(raw) == 0 {
+			continue
+		}
+		if i < len(decoders) {
+			out[i] = decoders[i].Decode(raw)
+		} else {
+			out[i] = string(raw)
+		}
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package main
+
+import "testing"
+
+func TestDecoderForCharset_Passthrough(t *testing.T) {
+	passthrough := []string{"", "utf8", "utf8mb4", "binary", "ascii", "UTF8MB4"}
+	for _, cs := range passthrough {
+		if d := decoderForCharset(cs); d != nil {
+			t.Errorf("decoderForCharset(%q) = %v, want nil (passthrough)", cs, d)
+		}
+	}
+}
+
+func TestDecoderForCharset_Known(t *testing.T) {
+	known := []string{"latin1", "ucs2", "utf16", "utf16le", "utf32", "gbk", "gb18030", "big5", "sjis", "euckr"}
+	for _, cs := range known {
+		if d := decoderForCharset(cs); d == nil {
+			t.Errorf("decoderForCharset(%q) = nil, want a decoder", cs)
+		}
+	}
+}
+
+func TestDecoderForCharset_Unknown(t *testing.T) {
+	if d := decoderForCharset("swahili7"); d != nil {
+		t.Errorf("decoderForCharset(unknown) = %v, want nil", d)
+	}
+}
+
+func TestBuildColumnDecoders(t *testing.T) {
+	schema := &TableSchema{
+		Columns: []ColumnInfo{
+			{Name: "id", DataType: "int", Charset: ""},
+			{Name: "name", DataType: "varchar", Charset: "latin1"},
+			{Name: "bio", DataType: "text", Charset: "utf8mb4"},
+		},
+	}
+	decoders := BuildColumnDecoders(schema)
+	if len(decoders) != 3 {
+		t.Fatalf("expected 3 decoders, got %d", len(decoders))
+	}
+	if decoders[0].Decoder != nil {
+		t.Error("expected passthrough decoder for int column with no charset")
+	}
+	if decoders[1].Decoder == nil {
+		t.Error("expected a latin1 decoder for name column")
+	}
+	if decoders[2].Decoder != nil {
+		t.Error("expected passthrough decoder for utf8mb4 column")
+	}
+}
+
+func TestColumnDecoder_DecodePassthrough(t *testing.T) {
+	d := ColumnDecoder{Name: "id"}
+	if got := d.Decode([]byte("hello")); got != "hello" {
+		t.Errorf("Decode passthrough = %q, want %q", got, "hello")
+	}
+}
+
+func TestColumnDecoder_DecodeLatin1(t *testing.T) {
+	d := ColumnDecoder{Name: "name", Charset: "latin1", Decoder: decoderForCharset("latin1")}
+	// 0xE9 in Windows-1252/latin1 is U+00E9 (é)
+	got := d.Decode([]byte{0xE9})
+	if got != "é" {
+		t.Errorf("Decode latin1 0xE9 = %q, want %q", got, "é")
+	}
+}
+
+func TestBatchConvertValues(t *testing.T) {
+	decoders := []ColumnDecoder{
+		{Name: "id"},
+		{Name: "name", Charset: "latin1", Decoder: decoderForCharset("latin1")},
+	}
+	values := []interface{}{int64(1), []byte{0xE9}}
+
+	out := batchConvertValues(decoders, values)
+	if out[0] != int64(1) {
+		t.Errorf("non-string value should pass through unchanged, got %v", out[0])
+	}
+	if out[1] != "é" {
+		t.Errorf("out[1] = %v, want %q", out[1], "é")
+	}
+}
+
+func TestBatchConvertValues_NilAndEmpty(t *testing.T) {
+	decoders := []ColumnDecoder{{Name: "a"}, {Name: "b"}}
+	values := []interface{}{nil, []byte{}}
+
+	out := batchConvertValues(decoders, values)
+	if out[0] != nil {
+		t.Errorf("nil value should stay nil, got %v", out[0])
+	}
+	if out[1] != nil {
+		t.Errorf("empty byte value should become NULL, got %v", out[1])
+	}
+}
+
+func TestColumnDecoder_DecodeHeuristicFallback(t *testing.T) {
+	d := ColumnDecoder{Heuristic: true}
+	input := []byte{
+		0x00, 0x00, 0x00, 0x41, // A
+		0x00, 0x00, 0x00, 0x42, // B
+	}
+	if got, want := d.Decode(input), "AB"; got != want {
+		t.Errorf("heuristic Decode(UTF-32 bytes) = %q, want %q", got, want)
+	}
+}
+
+func TestHeuristicColumnDecoders(t *testing.T) {
+	decoders := heuristicColumnDecoders(3)
+	if len(decoders) != 3 {
+		t.Fatalf("expected 3 decoders, got %d", len(decoders))
+	}
+	for i, d := range decoders {
+		if !d.Heuristic {
+			t.Errorf("decoders[%d].Heuristic = false, want true", i)
+		}
+	}
+}
+
+func TestKnownCharsets_MatchDecoderForCharset(t *testing.T) {
+	// Every charset decoderForCharset explicitly recognizes - whether
+	// mapped to a decoder or treated as intentional passthrough - must
+	// also be in knownCharsets, so BuildColumnDecoders never warns about
+	// a charset this tool actually knows how to handle.
+	for _, cs := range []string{"", "utf8", "utf8mb4", "binary", "ascii",
+		"latin1", "ucs2", "utf16", "utf16le", "utf32", "gbk", "gb18030", "big5", "sjis", "euckr"} {
+		if !knownCharsets[cs] {
+			t.Errorf("knownCharsets missing %q, which decoderForCharset recognizes", cs)
+		}
+	}
+	if knownCharsets["swahili7"] {
+		t.Error("knownCharsets should not contain an unrecognized charset")
+	}
+}
+
+func TestBatchConvertValues_ExtraValuesPassThroughUndecoded(t *testing.T) {
+	decoders := []ColumnDecoder{{Name: "a"}}
+	values := []interface{}{[]byte("x"), []byte("y")}
+
+	out := batchConvertValues(decoders, values)
+	if out[1] != "y" {
+		t.Errorf("value past end of decoders should pass through as string, got %v", out[1])
+	}
+}
@@ -6,24 +6,50 @@ import (
 )
 
 type Config struct {
-	SrcDSN           string
-	TgtDSN           string
-	SrcDB            string
-	TgtDB            string
-	SrcTable         string
-	TargetTable      string
-	ParallelWorkers  int
-	BatchSize        int
-	DBRetryAttempts  int
-	DBRetryMaxWait   int
-	FullloadRetries  int
-	FullloadDrop     bool
-	CheckpointTable  string
-	CheckpointEvery  int
-	CheckpointPeriod int
-	ServerID         uint32
-	HealthPort       int
-	Workers          int
+	SrcDSN                string
+	TgtDSN                string
+	SrcDB                 string
+	TgtDB                 string
+	SrcTable              string
+	TargetTable           string
+	ParallelWorkers       int
+	BatchSize             int
+	DBRetryAttempts       int
+	DBRetryMaxWait        int
+	FullloadRetries       int
+	FullloadDrop          bool
+	CheckpointTable       string
+	CheckpointEvery       int
+	CheckpointPeriod      int
+	ServerID              uint32
+	HealthPort            int
+	Workers               int
+	CutoverEnabled        bool
+	CutoverLagThresholdMs int
+	CutoverTimeoutSec     int
+	ThrottleReplicaDSN    string
+	ThrottleLagSoftSec    int64
+	ThrottleLagHardSec    int64
+	ThrottleQuery         string
+	ThrottleFlagFile      string
+	MaxLoadThreadsRunning int
+	LameDuckSeconds       int
+	ReplicationMode       string // "filepos" or "gtid"
+	LoadMethod            string // "extended_insert" or "load_data_infile"
+	MinChunkSize          int    // floor executeBatchInsert's adaptive sub-chunk size can shrink to
+	MaxChunkSize          int    // ceiling executeBatchInsert's adaptive sub-chunk size can grow to
+	MaxInFlightConns      int    // concurrencyGovernor: max concurrent INSERT connections per target table
+	MaxInFlightRows       int    // concurrencyGovernor: max total rows across in-flight batches per target table
+	VerifyChecksum        bool   // run VerifyTable's per-range BIT_XOR(CRC32(...)) comparison after a ranged full load
+	BatchMaxRows          int    // CDC apply: flush the buffered target transaction after this many buffered row ops
+	BatchMaxBytes         int    // CDC apply: flush the buffered target transaction after this many buffered bytes
+	CDCSinkDSN            string // CDC row delivery target: "" for the default MySQL sink, or "kafka://broker1:9092,broker2:9092/topic" for Debezium-style JSON
+	DDLUnsafePolicy       string // "reject" (default) or "allow" - whether SchemaTracker applies DDL that drops a column off the tracked table
+	ApplyWorkers          int    // MySQLCDCSink: number of per-primary-key buckets a source transaction's row ops are hashed into; 1 (default) preserves the original single-transaction atomicity, >1 trades it for concurrent per-bucket commits
+	ApplyQueueDepth       int    // MySQLCDCSink: ops capacity each apply bucket preallocates, to avoid reallocation churn while a source transaction buffers
+	WarnRowBytes          int    // log a structured warning when a CDC row's encoded size exceeds this many bytes; 0 disables the check
+	WarnColumnBytes       int    // log a structured warning when any single column's encoded size exceeds this many bytes; 0 disables the check
+	CheckpointDSN         string // MySQL DSN for bookkeeping (checkpoints, full-load progress, cutover/schema-tracker state); defaults to TGT_DSN when unset. Set this explicitly when TGT_DSN names a postgres:// or file:// sink, since those aren't MySQL connections this tool can keep bookkeeping tables in.
 }
 
 func LoadConfig() Config {
@@ -57,24 +83,83 @@ func LoadConfig() Config {
 		// &readTimeout=3600s - prevents timeout on large reads (1 hour for slow MariaDB)
 		// &timeout=60s - connection timeout (1 minute)
 		// &charset=utf8mb4 - ensures proper charset conversion for utf32 tables
-		SrcDSN:           p("SRC_DSN", "root:rootpass@tcp(source-host:3306)/?maxAllowedPacket=67108864&readTimeout=3600s&writeTimeout=3600s&timeout=60s&charset=utf8mb4"),
-		TgtDSN:           p("TGT_DSN", "root:rootpass@tcp(target-host:3306)/?maxAllowedPacket=67108864&writeTimeout=3600s&readTimeout=3600s&timeout=60s&charset=utf8mb4"),
-		SrcDB:            p("SRC_DB", "offercraft"),
-		TgtDB:            p("TGT_DB", "offercraft"),
-		SrcTable:         p("SRC_TABLE", "channel_transactions"),
-		TargetTable:      p("TARGET_TABLE", "channel_transactions_temp"),
-		ParallelWorkers:  toInt("PARALLEL_WORKERS", 8),  // Increased for large datasets
-		BatchSize:        toInt("BATCH_SIZE", 50000),    // Large batches for throughput (30min timeout)
-		DBRetryAttempts:  toInt("DB_RETRY_ATTEMPTS", 5),
-		DBRetryMaxWait:   toInt("DB_RETRY_MAX_WAIT", 10),
-		FullloadRetries:  toInt("FULLLOAD_MAX_RETRIES", 3),
-		FullloadDrop:     toBool("FULLLOAD_DROP_ON_RETRY", true),
-		CheckpointTable:  p("CHECKPOINT_TABLE", "cdc_checkpoints"),
-		CheckpointEvery:  toInt("CHECKPOINT_EVERY", 100),
-		CheckpointPeriod: toInt("CHECKPOINT_WRITE_SECONDS", 5),
-		ServerID:         uint32(toInt("BINLOG_SERVER_ID", 9999)),
-		HealthPort:       toInt("HEALTH_PORT", 8080),
-		Workers:          toInt("PARALLEL_WORKERS", 8),
+		SrcDSN:                p("SRC_DSN", "root:rootpass@tcp(source-host:3306)/?maxAllowedPacket=67108864&readTimeout=3600s&writeTimeout=3600s&timeout=60s&charset=utf8mb4"),
+		TgtDSN:                p("TGT_DSN", "root:rootpass@tcp(target-host:3306)/?maxAllowedPacket=67108864&writeTimeout=3600s&readTimeout=3600s&timeout=60s&charset=utf8mb4"),
+		SrcDB:                 p("SRC_DB", "offercraft"),
+		TgtDB:                 p("TGT_DB", "offercraft"),
+		SrcTable:              p("SRC_TABLE", "channel_transactions"),
+		TargetTable:           p("TARGET_TABLE", "channel_transactions_temp"),
+		ParallelWorkers:       toInt("PARALLEL_WORKERS", 8), // Increased for large datasets
+		BatchSize:             toInt("BATCH_SIZE", 50000),   // Large batches for throughput (30min timeout)
+		DBRetryAttempts:       toInt("DB_RETRY_ATTEMPTS", 5),
+		DBRetryMaxWait:        toInt("DB_RETRY_MAX_WAIT", 10),
+		FullloadRetries:       toInt("FULLLOAD_MAX_RETRIES", 3),
+		FullloadDrop:          toBool("FULLLOAD_DROP_ON_RETRY", true),
+		CheckpointTable:       p("CHECKPOINT_TABLE", "cdc_checkpoints"),
+		CheckpointEvery:       toInt("CHECKPOINT_EVERY", 100),
+		CheckpointPeriod:      toInt("CHECKPOINT_WRITE_SECONDS", 5),
+		ServerID:              uint32(toInt("BINLOG_SERVER_ID", 9999)),
+		HealthPort:            toInt("HEALTH_PORT", 8080),
+		Workers:               toInt("PARALLEL_WORKERS", 8),
+		CutoverEnabled:        toBool("CUTOVER_ENABLED", false),
+		CutoverLagThresholdMs: toInt("CUTOVER_LAG_THRESHOLD_MS", 1000),
+		CutoverTimeoutSec:     toInt("CUTOVER_TIMEOUT_SEC", 30),
+		ThrottleReplicaDSN:    p("THROTTLE_REPLICA_DSN", ""),
+		ThrottleLagSoftSec:    int64(toInt("THROTTLE_LAG_SOFT_SEC", 10)),
+		ThrottleLagHardSec:    int64(toInt("THROTTLE_LAG_HARD_SEC", 60)),
+		ThrottleQuery:         p("THROTTLE_QUERY", ""),
+		ThrottleFlagFile:      p("THROTTLE_FLAG_FILE", ""),
+		MaxLoadThreadsRunning: toInt("MAX_LOAD", 0),
+		LameDuckSeconds:       toInt("LAME_DUCK_SECONDS", 15),
+		ReplicationMode:       p("REPLICATION_MODE", "filepos"),
+		LoadMethod:            p("LOAD_METHOD", "extended_insert"),
+		MinChunkSize:          toInt("MIN_CHUNK_SIZE", 100),
+		MaxChunkSize:          toInt("MAX_CHUNK_SIZE", 5000),
+		MaxInFlightConns:      toInt("MAX_INFLIGHT_CONNS", 8),
+		MaxInFlightRows:       toInt("MAX_INFLIGHT_ROWS", 200000),
+		VerifyChecksum:        toBool("VERIFY_CHECKSUM", true),
+		BatchMaxRows:          toInt("BATCH_MAX_ROWS", 1000),
+		BatchMaxBytes:         toInt("BATCH_MAX_BYTES", 4*1024*1024),
+		CDCSinkDSN:            p("CDC_SINK_DSN", ""),
+		DDLUnsafePolicy:       p("DDL_UNSAFE_POLICY", "reject"),
+		ApplyWorkers:          toInt("APPLY_WORKERS", 1),
+		ApplyQueueDepth:       toInt("APPLY_QUEUE_DEPTH", 256),
+		WarnRowBytes:          toInt("WARN_ROW_BYTES", 1024*1024),
+		WarnColumnBytes:       toInt("WARN_COLUMN_BYTES", 256*1024),
+		CheckpointDSN:         p("CHECKPOINT_DSN", ""),
+	}
+	if cfg.ReplicationMode != "filepos" && cfg.ReplicationMode != "gtid" {
+		cfg.ReplicationMode = "filepos"
+	}
+	if cfg.LoadMethod != "extended_insert" && cfg.LoadMethod != "load_data_infile" {
+		cfg.LoadMethod = "extended_insert"
+	}
+	if cfg.MinChunkSize <= 0 {
+		cfg.MinChunkSize = 100
+	}
+	if cfg.MaxChunkSize < cfg.MinChunkSize {
+		cfg.MaxChunkSize = cfg.MinChunkSize
+	}
+	if cfg.MaxInFlightConns <= 0 {
+		cfg.MaxInFlightConns = 8
+	}
+	if cfg.MaxInFlightRows <= 0 {
+		cfg.MaxInFlightRows = 200000
+	}
+	if cfg.BatchMaxRows <= 0 {
+		cfg.BatchMaxRows = 1000
+	}
+	if cfg.BatchMaxBytes <= 0 {
+		cfg.BatchMaxBytes = 4 * 1024 * 1024
+	}
+	if cfg.DDLUnsafePolicy != "reject" && cfg.DDLUnsafePolicy != "allow" {
+		cfg.DDLUnsafePolicy = "reject"
+	}
+	if cfg.ApplyWorkers <= 0 {
+		cfg.ApplyWorkers = 1
+	}
+	if cfg.ApplyQueueDepth <= 0 {
+		cfg.ApplyQueueDepth = 256
 	}
 	return cfg
 }
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// CutoverState tracks the outcome of an atomic table swap, persisted so a
+// restart after a crashed cutover can tell whether the rename already
+// happened.
+type CutoverState struct {
+	Key        string
+	TargetFile string
+	TargetPos  uint32
+	Status     string // pending, done, failed
+	UpdatedAt  time.Time
+}
+
+// EnsureCutoverTable creates the table that records cut-over outcomes.
+func EnsureCutoverTable(db *sql.DB) error {
+	q := `
+CREATE TABLE IF NOT EXISTS cdc_cutover_state (
+  table_key VARCHAR(255) PRIMARY KEY,
+  target_file VARCHAR(255),
+  target_pos BIGINT,
+  status VARCHAR(32),
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+)`
+	_, err := db.Exec(q)
+	return err
+}
+
+func writeCutoverState(db *sql.DB, key, file string, pos uint32, status string) error {
+	q := `INSERT INTO cdc_cutover_state (table_key, target_file, target_pos, status) VALUES (?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE target_file=VALUES(target_file), target_pos=VALUES(target_pos), status=VALUES(status)`
+	_, err := db.Exec(q, key, file, pos, status)
+	return err
+}
+
+func readCutoverState(db *sql.DB, key string) (CutoverState, error) {
+	q := `SELECT target_file, target_pos, status FROM cdc_cutover_state WHERE table_key = ?`
+	row := db.QueryRow(q, key)
+	var s CutoverState
+	s.Key = key
+	err := row.Scan(&s.TargetFile, &s.TargetPos, &s.Status)
+	return s, err
+}
+
+// CutoverCoordinator watches the CDC apply position and, once it reaches
+// the target position captured at cut-over start, performs an atomic
+// RENAME TABLE swap so the target table becomes the live table.
+type CutoverCoordinator struct {
+	cfg        Config
+	tgtDB      *sql.DB
+	targetFile string
+	targetPos  uint32
+	done       bool
+}
+
+// NewCutoverCoordinator captures the current source master position as the
+// cut-over target and records it, so progress can be tracked across
+// restarts. Returns nil if cut-over is disabled.
+func NewCutoverCoordinator(cfg Config, srcDB, tgtDB *sql.DB) (*CutoverCoordinator, error) {
+	if !cfg.CutoverEnabled {
+		return nil, nil
+	}
+	if err := EnsureCutoverTable(tgtDB); err != nil {
+		return nil, fmt.Errorf("ensure cutover table: %v", err)
+	}
+
+	key := keyFor(cfg)
+	if state, err := readCutoverState(tgtDB, key); err == nil && state.Status == "done" {
+		log.Printf("Cut-over already completed for %s, skipping", key)
+		return &CutoverCoordinator{cfg: cfg, tgtDB: tgtDB, done: true}, nil
+	}
+
+	file, pos, err := getSourceMasterStatus(srcDB)
+	if err != nil {
+		return nil, fmt.Errorf("capture cutover target position: %v", err)
+	}
+	if err := writeCutoverState(tgtDB, key, file, pos, "pending"); err != nil {
+		return nil, fmt.Errorf("record cutover target: %v", err)
+	}
+	log.Printf("Cut-over armed: will swap tables once CDC reaches %s:%d", file, pos)
+
+	return &CutoverCoordinator{
+		cfg:        cfg,
+		tgtDB:      tgtDB,
+		targetFile: file,
+		targetPos:  pos,
+	}, nil
+}
+
+// CheckAndCutover is invoked on every checkpoint tick from the CDC apply
+// loop. Once the current apply position has caught up to the target
+// position (and replication lag is under the configured threshold), it
+// performs the atomic swap.
+func (c *CutoverCoordinator) CheckAndCutover(currentFile string, currentPos uint32) {
+	if c == nil || c.done {
+		return
+	}
+	if !binlogPosGTE(currentFile, currentPos, c.targetFile, c.targetPos) {
+		return
+	}
+	lagMs := globalMetrics.ReplicationLagSec * 1000
+	if lagMs > float64(c.cfg.CutoverLagThresholdMs) {
+		log.Printf("Cut-over target position reached but lag %.0fms exceeds threshold %dms, waiting", lagMs, c.cfg.CutoverLagThresholdMs)
+		return
+	}
+
+	log.Println("Cut-over target reached, performing atomic table swap")
+	if err := c.performSwap(); err != nil {
+		log.Println("Cut-over failed, continuing to apply CDC:", err)
+		_ = writeCutoverState(c.tgtDB, keyFor(c.cfg), c.targetFile, c.targetPos, "failed")
+		return
+	}
+	c.done = true
+	if err := writeCutoverState(c.tgtDB, keyFor(c.cfg), c.targetFile, c.targetPos, "done"); err != nil {
+		log.Println("Error recording cutover completion:", err)
+	}
+	log.Println("Cut-over complete: target table is now live")
+}
+
+func (c *CutoverCoordinator) performSwap() error {
+	db := c.tgtDB
+	cfg := c.cfg
+	original := fmt.Sprintf("`%s`.`%s`", cfg.TgtDB, cfg.SrcTable)
+	originalOld := fmt.Sprintf("`%s`.`%s`", cfg.TgtDB, cfg.SrcTable+"_old")
+	tempTable := fmt.Sprintf("`%s`.`%s`", cfg.TgtDB, cfg.TargetTable)
+
+	timeout := time.Duration(cfg.CutoverTimeoutSec) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire cutover connection: %v", err)
+	}
+	defer conn.Close()
+
+	// RENAME TABLE is already atomic across the whole list of pairs - it
+	// takes the metadata locks it needs and blocks other sessions from
+	// seeing either table under any name until all renames commit together.
+	// A session-held LOCK TABLES before it is not just unnecessary but
+	// actively fatal: MySQL refuses to run RENAME TABLE while the current
+	// session holds an explicit table lock (error 1192), so this swap could
+	// never have succeeded with one in place.
+	renameQuery := fmt.Sprintf("RENAME TABLE %s TO %s, %s TO %s", original, originalOld, tempTable, original)
+	if _, err := conn.ExecContext(ctx, renameQuery); err != nil {
+		return fmt.Errorf("rename table: %v", err)
+	}
+	return nil
+}
+
+// binlogPosGTE reports whether (file, pos) is at or past (targetFile,
+// targetPos), assuming the standard fixed-width binlog sequence numbering
+// so that file names sort lexically.
+func binlogPosGTE(file string, pos uint32, targetFile string, targetPos uint32) bool {
+	if file == targetFile {
+		return pos >= targetPos
+	}
+	return file > targetFile
+}
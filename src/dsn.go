@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// DSNInfo is the structured form of a DSN, parsed via go-sql-driver's own
+// ParseDSN rather than ad-hoc substring scanning. This correctly handles
+// IPv6 literals, unix sockets, passwords containing "@"/":" and DSNs with
+// query parameters, none of which the old extractXFromDSN helpers could.
+type DSNInfo struct {
+	Host   string
+	Port   uint16
+	User   string
+	Passwd string
+	Net    string
+	DBName string
+	Params map[string]string
+}
+
+// parseDSN parses a go-sql-driver DSN into a DSNInfo. Falls back to a DSNInfo
+// with sane defaults if the DSN can't be parsed, mirroring the old helpers'
+// best-effort behavior.
+func parseDSN(dsn string) (DSNInfo, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return DSNInfo{Host: "127.0.0.1", Port: 3306, User: "root", Net: "tcp"}, err
+	}
+
+	info := DSNInfo{
+		User:   cfg.User,
+		Passwd: cfg.Passwd,
+		Net:    cfg.Net,
+		DBName: cfg.DBName,
+		Params: cfg.Params,
+	}
+
+	switch cfg.Net {
+	case "unix":
+		// unix socket address has no host/port split
+		info.Host = cfg.Addr
+	default:
+		host, portStr, err := net.SplitHostPort(cfg.Addr)
+		if err != nil {
+			info.Host = cfg.Addr
+			info.Port = 3306
+			break
+		}
+		info.Host = host
+		if p, err := strconv.ParseUint(portStr, 10, 16); err == nil {
+			info.Port = uint16(p)
+		} else {
+			info.Port = 3306
+		}
+	}
+
+	return info, nil
+}
+
+// extractHostFromDSN keeps the old call sites working while delegating to
+// the structured DSN parser.
+func extractHostFromDSN(dsn string) string {
+	info, err := parseDSN(dsn)
+	if err != nil {
+		return "127.0.0.1"
+	}
+	return info.Host
+}
+
+func extractPortFromDSN(dsn string) uint16 {
+	info, err := parseDSN(dsn)
+	if err != nil || info.Port == 0 {
+		return 3306
+	}
+	return info.Port
+}
+
+func extractUserFromDSN(dsn string) string {
+	info, err := parseDSN(dsn)
+	if err != nil || info.User == "" {
+		return "root"
+	}
+	return info.User
+}
+
+func extractPassFromDSN(dsn string) string {
+	info, err := parseDSN(dsn)
+	if err != nil {
+		return ""
+	}
+	return info.Passwd
+}
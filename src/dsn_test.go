@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+func TestParseDSN_IPv6(t *testing.T) {
+	dsn := "user:pass@tcp([2001:db8::1]:3306)/db"
+	info, err := parseDSN(dsn)
+	if err != nil {
+		t.Fatalf("parseDSN(%q) returned error: %v", dsn, err)
+	}
+	if info.Host != "2001:db8::1" {
+		t.Errorf("Host = %q, want %q", info.Host, "2001:db8::1")
+	}
+	if info.Port != 3306 {
+		t.Errorf("Port = %d, want 3306", info.Port)
+	}
+}
+
+func TestParseDSN_UnixSocket(t *testing.T) {
+	dsn := "user:pass@unix(/tmp/mysql.sock)/db"
+	info, err := parseDSN(dsn)
+	if err != nil {
+		t.Fatalf("parseDSN(%q) returned error: %v", dsn, err)
+	}
+	if info.Net != "unix" {
+		t.Errorf("Net = %q, want %q", info.Net, "unix")
+	}
+	if info.Host != "/tmp/mysql.sock" {
+		t.Errorf("Host = %q, want %q", info.Host, "/tmp/mysql.sock")
+	}
+}
+
+func TestParseDSN_PasswordWithSpecialChars(t *testing.T) {
+	// go-sql-driver DSNs disambiguate on the LAST '@' before the address,
+	// so passwords containing '@' or ':' parse correctly where the old
+	// naive first-':'/first-'@' scan would have truncated them.
+	dsn := "user:p@ss:word@tcp(localhost:3306)/db"
+	info, err := parseDSN(dsn)
+	if err != nil {
+		t.Fatalf("parseDSN(%q) returned error: %v", dsn, err)
+	}
+	if info.Passwd != "p@ss:word" {
+		t.Errorf("Passwd = %q, want %q", info.Passwd, "p@ss:word")
+	}
+}
+
+func TestParseDSN_WithQueryParams(t *testing.T) {
+	dsn := "user:pass@tcp(localhost:3306)/db?charset=utf8mb4&timeout=60s"
+	info, err := parseDSN(dsn)
+	if err != nil {
+		t.Fatalf("parseDSN(%q) returned error: %v", dsn, err)
+	}
+	if info.DBName != "db" {
+		t.Errorf("DBName = %q, want %q", info.DBName, "db")
+	}
+	if info.Params["charset"] != "utf8mb4" {
+		t.Errorf("Params[charset] = %q, want %q", info.Params["charset"], "utf8mb4")
+	}
+}
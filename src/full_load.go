@@ -1,31 +1,46 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/go-sql-driver/mysql"
 )
 
-// batchInsertJob contains everything needed for a batch insert
+// batchInsertJob contains everything needed for a batch insert. seq and
+// cursor are only used by streamingLoad's pipeline, to let the inserter
+// goroutines report completed batches back to a streamCheckpointTracker;
+// loadRange/chunkedKeyLoad's range-based checkpointing doesn't need them.
 type batchInsertJob struct {
 	cols      []string
 	batchRows [][]interface{}
+	seq       int64
+	cursor    []interface{}
 }
 
-func runFullLoad(cfg Config, srcDB, tgtDB *sql.DB) (string, uint32, error) {
+func runFullLoad(ctx context.Context, cfg Config, srcDB, tgtDB *sql.DB, sink TargetSink) (string, uint32, error) {
 	key := fmt.Sprintf("%s.%s.%s", cfg.SrcDSN, cfg.SrcDB, cfg.SrcTable)
-	
+	throttler := NewThrottler(cfg, srcDB)
+	defer throttler.Close()
+	gov := newConcurrencyGovernor(cfg)
+
 	// Use target database
 	if _, err := tgtDB.Exec(fmt.Sprintf("USE `%s`", cfg.TgtDB)); err != nil {
 		return "", 0, err
 	}
-	
+
 	// prepare target
-	log.Println("Copying schema to target table:", cfg.TargetTable)
-	if err := CopyTableSchema(srcDB, tgtDB, cfg.SrcDB, cfg.SrcTable, cfg.TgtDB, cfg.TargetTable); err != nil {
+	log.Printf("Preparing target table via %s sink: %s\n", sink.Name(), cfg.TargetTable)
+	if err := sink.PrepareSchema(srcDB, cfg); err != nil {
 		return "", 0, err
 	}
 
@@ -39,6 +54,25 @@ func runFullLoad(cfg Config, srcDB, tgtDB *sql.DB) (string, uint32, error) {
 		return "", 0, err
 	}
 
+	// In GTID mode, capture the executed GTID set inside a consistent-snapshot
+	// transaction taken right before backfill reads start, rather than after
+	// they finish. This narrows the drift window between "the position CDC
+	// will resume from" and "the data the backfill actually saw": any row
+	// changed between this point and the end of the backfill is simply
+	// replayed again by CDC, which REPLACE/upsert makes idempotent.
+	var gtidAtStart string
+	if cfg.ReplicationMode == "gtid" {
+		g, err := captureGTIDConsistent(srcDB)
+		if err != nil {
+			return "", 0, fmt.Errorf("capture consistent GTID snapshot: %v", err)
+		}
+		if g == "" {
+			return "", 0, fmt.Errorf("replication mode is gtid but source has no executed GTID set (is gtid_mode=ON?)")
+		}
+		gtidAtStart = g
+		log.Printf("Captured consistent GTID snapshot before backfill: %s", gtidAtStart)
+	}
+
 	pkCol, ok, err := DetectSingleIntPK(srcDB, cfg.SrcDB, cfg.SrcTable)
 	if err != nil {
 		return "", 0, err
@@ -52,11 +86,30 @@ func runFullLoad(cfg Config, srcDB, tgtDB *sql.DB) (string, uint32, error) {
 		}
 		if mn == 0 && mx == 0 {
 			log.Println("Empty table, performing streaming load")
-			if err := streamingLoad(cfg, srcDB, tgtDB); err != nil {
+			if err := streamingLoad(ctx, cfg, srcDB, tgtDB, sink, throttler, gov); err != nil {
 				return "", 0, err
 			}
 		} else {
-			taskRanges := buildRanges(mn, mx, cfg.ParallelWorkers)
+			// Reuse a prior run's planned ranges if this table_key already has
+			// some: buildStatisticalRanges reflects the table's current row
+			// density, so recomputing it after a restart - once rows have been
+			// inserted or deleted by the time we resume - would drift out of
+			// step with already-completed ranges recorded against the old
+			// boundaries.
+			taskRanges, err := GetPlannedRanges(tgtDB, key)
+			if err != nil {
+				return "", 0, err
+			}
+			if len(taskRanges) == 0 {
+				taskRanges, err = buildStatisticalRanges(srcDB, cfg.SrcDB, cfg.SrcTable, pkCol, mn, mx, cfg.ParallelWorkers)
+				if err != nil {
+					log.Printf("Warning: statistics-driven range split failed (%v), falling back to equal-width ranges", err)
+					taskRanges = buildRanges(mn, mx, cfg.ParallelWorkers)
+				}
+				if err := MarkRangesPlanned(tgtDB, key, taskRanges); err != nil {
+					return "", 0, err
+				}
+			}
 			// skip already-done ranges
 			done, err := GetDoneRanges(tgtDB, key)
 			if err != nil {
@@ -69,17 +122,29 @@ func runFullLoad(cfg Config, srcDB, tgtDB *sql.DB) (string, uint32, error) {
 				log.Printf("Starting %d worker(s) for %d ranges\n", cfg.ParallelWorkers, len(tasks))
 				rangeCh := make(chan [2]int64, len(tasks))
 				var wg sync.WaitGroup
+				var workerErrMu sync.Mutex
+				var workerErr error
 				for i := 0; i < cfg.ParallelWorkers; i++ {
 					wg.Add(1)
 					go func(workerId int) {
 						defer wg.Done()
 						for rng := range rangeCh {
-							if err := loadRange(cfg, srcDB, tgtDB, pkCol, rng[0], rng[1]); err != nil {
+							if err := loadRange(ctx, cfg, srcDB, tgtDB, sink, pkCol, rng[0], rng[1], throttler, gov); err != nil {
+								if err == ErrShutdown {
+									log.Printf("Worker %d: shutdown requested, abandoning range %d-%d\n", workerId, rng[0], rng[1])
+									return
+								}
 								log.Printf("Worker %d: range %d-%d failed: %v\n", workerId, rng[0], rng[1], err)
-								// on persistent failure, stop and bubble up
-								// writing to progress is not done for failed range
-								// caller may choose to retry full-load
-								os.Exit(1)
+								// on persistent failure, stop this worker and bubble the
+								// error up to runFullLoad's caller, which retries the whole
+								// full load - writing to progress is not done for the
+								// failed range, so a retry picks it back up via filterRanges.
+								workerErrMu.Lock()
+								if workerErr == nil {
+									workerErr = fmt.Errorf("worker %d: range %d-%d: %v", workerId, rng[0], rng[1], err)
+								}
+								workerErrMu.Unlock()
+								return
 							}
 						}
 					}(i)
@@ -89,12 +154,34 @@ func runFullLoad(cfg Config, srcDB, tgtDB *sql.DB) (string, uint32, error) {
 				}
 				close(rangeCh)
 				wg.Wait()
+				if ctx.Err() != nil {
+					return "", 0, ErrShutdown
+				}
+				if workerErr != nil {
+					return "", 0, workerErr
+				}
+			}
+			if cfg.VerifyChecksum {
+				if err := VerifyTable(ctx, cfg, srcDB, tgtDB, pkCol, taskRanges); err != nil {
+					return "", 0, fmt.Errorf("post-load checksum verification failed: %v", err)
+				}
 			}
 		}
 	} else {
-		log.Println("No single integer PK; performing streaming load")
-		if err := streamingLoad(cfg, srcDB, tgtDB); err != nil {
-			return "", 0, err
+		chunkCols, ckErr := DiscoverChunkKey(srcDB, cfg.SrcDB, cfg.SrcTable)
+		if ckErr != nil {
+			return "", 0, ckErr
+		}
+		if len(chunkCols) > 0 {
+			log.Println("No single integer PK; chunking by unique key:", chunkCols)
+			if err := chunkedKeyLoad(ctx, cfg, srcDB, tgtDB, sink, chunkCols, throttler); err != nil {
+				return "", 0, err
+			}
+		} else {
+			log.Println("No usable unique key for chunking; performing streaming load")
+			if err := streamingLoad(ctx, cfg, srcDB, tgtDB, sink, throttler, gov); err != nil {
+				return "", 0, err
+			}
 		}
 	}
 
@@ -104,27 +191,40 @@ func runFullLoad(cfg Config, srcDB, tgtDB *sql.DB) (string, uint32, error) {
 	}
 
 	// capture master status from source
-	file, pos, err := captureMasterStatus(cfg, srcDB)
+	file, pos, gtidSet, err := captureMasterStatus(cfg, srcDB)
 	if err != nil {
 		return "", 0, err
 	}
+	if gtidAtStart != "" {
+		// Prefer the snapshot-consistent GTID set captured before backfill
+		// began over the one observed afterwards; CDC will simply re-apply
+		// anything committed on the source in between.
+		gtidSet = gtidAtStart
+	}
+	flavor := ""
+	if gtidSet != "" {
+		flavor = "mysql"
+	}
 	// write checkpoint to target DB - use fully qualified table name
 	checkpointTable := fmt.Sprintf("`%s`.`%s`", cfg.TgtDB, cfg.CheckpointTable)
 	if err := EnsureCheckpointTable(tgtDB, checkpointTable); err != nil {
 		return "", 0, err
 	}
-	if err := WriteCheckpoint(tgtDB, checkpointTable, key, file, pos); err != nil {
+	if err := WriteCheckpoint(tgtDB, checkpointTable, keyFor(cfg), file, pos, gtidSet, flavor); err != nil {
 		return "", 0, err
 	}
+	globalMetrics.UpdateCheckpoint(file, pos)
 	log.Printf("Wrote checkpoint %s:%d\n", file, pos)
 	return file, pos, nil
 }
 
-func captureMasterStatus(cfg Config, srcDB *sql.DB) (string, uint32, error) {
+// captureMasterStatus returns the source's current binlog file/pos and, when
+// available, its executed GTID set (empty when gtid_mode is off).
+func captureMasterStatus(cfg Config, srcDB *sql.DB) (string, uint32, string, error) {
 	row := srcDB.QueryRow("SHOW MASTER STATUS")
 	var file string
 	var pos uint32
-	
+
 	// Try 5 columns first (MySQL 5.7+, 8.0+)
 	var binlogDoDB, binlogIgnoreDB, executedGtidSet sql.NullString
 	err := row.Scan(&file, &pos, &binlogDoDB, &binlogIgnoreDB, &executedGtidSet)
@@ -133,11 +233,103 @@ func captureMasterStatus(cfg Config, srcDB *sql.DB) (string, uint32, error) {
 		row = srcDB.QueryRow("SHOW MASTER STATUS")
 		err = row.Scan(&file, &pos, &binlogDoDB, &binlogIgnoreDB)
 	}
-	
+
 	if err != nil {
-		return "", 0, err
+		return "", 0, "", err
 	}
-	return file, pos, nil
+	return file, pos, executedGtidSet.String, nil
+}
+
+// captureGTIDConsistent reads the source's executed GTID set inside a
+// START TRANSACTION WITH CONSISTENT SNAPSHOT, on a single dedicated
+// connection, so the read is tied to the same snapshot view the backfill
+// queries below are about to see. Note this only narrows the drift window:
+// the parallel range/chunk workers each open their own connection and do
+// not share this snapshot, so rows changed on the source after this
+// transaction starts but before a given worker reads them are still picked
+// up by the backfill rather than the snapshot — CDC re-applying those same
+// changes afterward is what makes this safe.
+func captureGTIDConsistent(srcDB *sql.DB) (string, error) {
+	ctx := context.Background()
+	conn, err := srcDB.Conn(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "START TRANSACTION WITH CONSISTENT SNAPSHOT"); err != nil {
+		return "", err
+	}
+	var gtidSet sql.NullString
+	err = conn.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_executed").Scan(&gtidSet)
+	if err != nil {
+		conn.ExecContext(ctx, "ROLLBACK")
+		return "", err
+	}
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return "", err
+	}
+	return gtidSet.String, nil
+}
+
+// buildStatisticalRanges splits [minv, maxv] into roughly numWorkers PK
+// ranges (uneven probe counts can yield a few more or fewer) balanced by
+// actual row count, rather than buildRanges' equal-width
+// split - which produces badly skewed workers once the PK is sparse (e.g.
+// after mass deletes) or clustered. It probes 2*numWorkers equal-width
+// buckets across [minv, maxv] with COUNT(*), clamping each probe's count to
+// a minimum of 1 (the same cardinality floor TiDB uses, so an apparently
+// empty bucket still becomes part of a range instead of vanishing), then
+// greedily coalesces adjacent probes until the running total reaches
+// TotalRows/numWorkers - the target (within a ~20% band below it, since
+// probes are twice as fine-grained as the final ranges, the actual overshoot
+// above target from including one more probe is bounded but not exact).
+func buildStatisticalRanges(db *sql.DB, schema, table, pkCol string, minv, maxv int64, numWorkers int) ([][2]int64, error) {
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	probes := buildRanges(minv, maxv, 2*numWorkers)
+	if len(probes) == 0 {
+		return nil, nil
+	}
+
+	counts := make([]int64, len(probes))
+	var total int64
+	q := fmt.Sprintf("SELECT COUNT(*) FROM `%s`.`%s` WHERE `%s` BETWEEN ? AND ?", schema, table, pkCol)
+	for i, p := range probes {
+		var c int64
+		if err := db.QueryRow(q, p[0], p[1]).Scan(&c); err != nil {
+			return nil, err
+		}
+		if c < 1 {
+			c = 1
+		}
+		counts[i] = c
+		total += c
+	}
+
+	target := total / int64(numWorkers)
+	if target < 1 {
+		target = 1
+	}
+	lowBand := target - target/5
+
+	var ranges [][2]int64
+	start := probes[0][0]
+	var running int64
+	for i, p := range probes {
+		running += counts[i]
+		if i == len(probes)-1 {
+			ranges = append(ranges, [2]int64{start, maxv})
+			break
+		}
+		if running >= lowBand {
+			ranges = append(ranges, [2]int64{start, p[1]})
+			start = p[1] + 1
+			running = 0
+		}
+	}
+	return ranges, nil
 }
 
 func buildRanges(minv, maxv int64, workers int) [][2]int64 {
@@ -177,11 +369,17 @@ func filterRanges(all [][2]int64, done [][2]int64) [][2]int64 {
 	return res
 }
 
-func loadRange(cfg Config, srcDB, tgtDB *sql.DB, pk string, start, end int64) error {
+func loadRange(ctx context.Context, cfg Config, srcDB, tgtDB *sql.DB, sink TargetSink, pk string, start, end int64, throttler *Throttler, gov *concurrencyGovernor) error {
 	log.Printf("Loading range %d - %d\n", start, end)
 	// streaming SELECT with LIMIT is fine for safety; we fetch by pk range batches
 	offset := start
 	for {
+		if ctx.Err() != nil {
+			return ErrShutdown
+		}
+		if err := throttler.Wait(); err != nil {
+			return err
+		}
 		q := fmt.Sprintf("SELECT * FROM `%s`.`%s` WHERE `%s` BETWEEN ? AND ? ORDER BY `%s` LIMIT %d", cfg.SrcDB, cfg.SrcTable, pk, pk, cfg.BatchSize)
 		rows, err := srcDB.Query(q, offset, end)
 		if err != nil {
@@ -198,25 +396,13 @@ func loadRange(cfg Config, srcDB, tgtDB *sql.DB, pk string, start, end int64) er
 			scanArgs[i] = &values[i]
 		}
 		count := 0
-		tx, err := tgtDB.Begin()
-		if err != nil {
-			rows.Close()
-			return err
-		}
-		// Use target database
-		if _, err := tx.Exec(fmt.Sprintf("USE `%s`", cfg.TgtDB)); err != nil {
-			rows.Close()
-			tx.Rollback()
-			return err
-		}
-		
+
 		// Optimization: Use extended INSERT for batch inserts (much faster for large datasets)
 		// Build multi-row INSERT statement
 		var batchRows [][]interface{}
 		for rows.Next() {
 			if err := rows.Scan(scanArgs...); err != nil {
 				rows.Close()
-				tx.Rollback()
 				return err
 			}
 			args := make([]interface{}, len(values))
@@ -231,26 +417,39 @@ func loadRange(cfg Config, srcDB, tgtDB *sql.DB, pk string, start, end int64) er
 			count++
 		}
 		rows.Close()
-		
+
 		if count > 0 {
-			// Execute batch insert with extended INSERT syntax
-			if err := executeBatchInsert(tx, cfg, cols, batchRows); err != nil {
-				tx.Rollback()
+			release, err := gov.forTable(cfg).Acquire(ctx, count)
+			if err != nil {
+				return err
+			}
+			err = sink.WriteBatch(cfg, cols, batchRows)
+			release()
+			if err != nil {
 				return err
 			}
-		}
-		
-		if err := tx.Commit(); err != nil {
-			return err
 		}
 		if count == 0 {
 			break
 		}
-		// compute next offset: read last pk from target for this range
-		var last int64
-		err = tgtDB.QueryRow(fmt.Sprintf("SELECT MAX(`%s`) FROM `%s`.`%s` WHERE `%s` BETWEEN ? AND ?", pk, cfg.TgtDB, cfg.TargetTable, pk), start, end).Scan(&last)
+		// Compute next offset from the pk of the last row of this batch
+		// rather than reading it back from the target: the target may not
+		// even be a queryable SQL database (e.g. a file sink), and the
+		// source batch is already ordered by pk, so its own last row is the
+		// correct cursor.
+		pkIdx := -1
+		for i, c := range cols {
+			if c == pk {
+				pkIdx = i
+				break
+			}
+		}
+		if pkIdx < 0 {
+			return fmt.Errorf("pk column %q not found in result columns", pk)
+		}
+		last, err := strconv.ParseInt(fmt.Sprintf("%v", batchRows[len(batchRows)-1][pkIdx]), 10, 64)
 		if err != nil {
-			return err
+			return fmt.Errorf("parse last pk %q: %v", pk, err)
 		}
 		if last >= end {
 			break
@@ -264,17 +463,32 @@ func loadRange(cfg Config, srcDB, tgtDB *sql.DB, pk string, start, end int64) er
 	return nil
 }
 
+// keyFor identifies a replication job by source host:port and schema/table
+// rather than the raw DSN string, so checkpoints stay stable across DSNs
+// that are syntactically different but point at the same source (e.g.
+// reordered query params).
 func keyFor(cfg Config) string {
-	return fmt.Sprintf("%s.%s.%s", cfg.SrcDSN, cfg.SrcDB, cfg.SrcTable)
+	info, err := parseDSN(cfg.SrcDSN)
+	if err != nil {
+		return fmt.Sprintf("%s.%s.%s", cfg.SrcDSN, cfg.SrcDB, cfg.SrcTable)
+	}
+	return fmt.Sprintf("%s:%d.%s.%s", info.Host, info.Port, cfg.SrcDB, cfg.SrcTable)
 }
 
 // executeBatchInsert performs optimized batch insert using extended INSERT syntax
 // This is much faster than individual inserts for large datasets (20-30M rows)
-func executeBatchInsert(tx *sql.Tx, cfg Config, cols []string, batchRows [][]interface{}) error {
+//
+// Each sub-chunk is sent through execChunkWithRetry, which retries
+// transient MySQL errors (deadlock, lock-wait timeout, connection loss)
+// with backoff and reports a 1153 packet-too-large back to chunker, so the
+// same rows are re-sent at the smaller size. Any other error, including a
+// retryable one that ran out of attempts, is returned without touching
+// chunker.
+func executeBatchInsert(tx *sql.Tx, cfg Config, cols []string, batchRows [][]interface{}, chunker *adaptiveChunkSize) error {
 	if len(batchRows) == 0 {
 		return nil
 	}
-	
+
 	// Build column list
 	var colNames []string
 	for _, col := range cols {
@@ -288,16 +502,15 @@ func executeBatchInsert(tx *sql.Tx, cfg Config, cols []string, batchRows [][]int
 		colList += name
 	}
 	colList += ")"
-	
-	// Split into chunks of 1000 rows to avoid max_allowed_packet limit
-	chunkSize := 1000
-	for i := 0; i < len(batchRows); i += chunkSize {
+
+	for i := 0; i < len(batchRows); {
+		chunkSize := chunker.Size()
 		end := i + chunkSize
 		if end > len(batchRows) {
 			end = len(batchRows)
 		}
 		chunk := batchRows[i:end]
-		
+
 		// Build extended INSERT with multiple value sets
 		placeholders := "("
 		for j := 0; j < len(cols); j++ {
@@ -307,7 +520,7 @@ func executeBatchInsert(tx *sql.Tx, cfg Config, cols []string, batchRows [][]int
 			placeholders += "?"
 		}
 		placeholders += ")"
-		
+
 		var valueSets string
 		var allArgs []interface{}
 		for idx, row := range chunk {
@@ -317,16 +530,157 @@ func executeBatchInsert(tx *sql.Tx, cfg Config, cols []string, batchRows [][]int
 			valueSets += placeholders
 			allArgs = append(allArgs, row...)
 		}
-		
+
 		query := fmt.Sprintf("INSERT INTO `%s`.`%s` %s VALUES %s", cfg.TgtDB, cfg.TargetTable, colList, valueSets)
-		if _, err := tx.Exec(query, allArgs...); err != nil {
+
+		start := time.Now()
+		err := execChunkWithRetry(tx, cfg, query, allArgs, chunker)
+		if err == errPacketTooLarge {
+			if chunkSize <= cfg.MinChunkSize {
+				// Already at the floor - a single row (or MinChunkSize of
+				// them) is itself too large, so no further shrinking will
+				// help; surface it instead of looping forever.
+				return fmt.Errorf("chunk at MIN_CHUNK_SIZE (%d) still rejected as packet too large", cfg.MinChunkSize)
+			}
+			// chunker already shrank; re-split this same range of rows at
+			// the new, smaller size instead of advancing past it.
+			continue
+		}
+		if err != nil {
 			return err
 		}
+		chunker.RecordSuccess(time.Since(start))
+		i = end
 	}
-	
+
 	return nil
 }
 
+// errPacketTooLarge signals that a chunk was rejected with MySQL error
+// 1153 (packet too large), so executeBatchInsert should retry the same
+// row range at chunker's now-smaller size rather than move on.
+var errPacketTooLarge = errors.New("chunk rejected: packet too large")
+
+// retryableMySQLErrors are error numbers worth retrying with backoff
+// before giving up on a chunk: deadlock (1213), lock wait timeout (1205),
+// read-only (1290, e.g. mid-failover), and connection loss (2006, 2013).
+var retryableMySQLErrors = map[uint16]bool{
+	1213: true,
+	1205: true,
+	1290: true,
+	2006: true,
+	2013: true,
+}
+
+const mysqlErrPacketTooLarge = 1153
+
+// execChunkWithRetry executes one multi-row INSERT, retrying transient
+// MySQL errors with exponential backoff and jitter, capped at
+// cfg.DBRetryAttempts (the same knob the rest of the tool's retry paths
+// use). A 1153 packet-too-large halves chunker's chunk size and returns
+// errPacketTooLarge without retrying this exact query (a smaller chunk is
+// a different query); any other error is permanent.
+func execChunkWithRetry(tx *sql.Tx, cfg Config, query string, args []interface{}, chunker *adaptiveChunkSize) error {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = 200 * time.Millisecond
+	b.MaxInterval = time.Duration(cfg.DBRetryMaxWait) * time.Second
+	b.RandomizationFactor = 0.5 // jitter
+
+	return backoff.Retry(func() error {
+		_, err := tx.Exec(query, args...)
+		if err == nil {
+			return nil
+		}
+
+		num := mysqlErrorNumber(err)
+		if num == mysqlErrPacketTooLarge {
+			chunker.Shrink()
+			return backoff.Permanent(errPacketTooLarge)
+		}
+		if retryableMySQLErrors[num] {
+			return err // retry
+		}
+		return backoff.Permanent(err)
+	}, backoff.WithMaxRetries(b, uint64(cfg.DBRetryAttempts)))
+}
+
+// mysqlErrorNumber extracts the MySQL server error number from err, or 0
+// if err isn't a *mysql.MySQLError (e.g. a driver/network error, which
+// retryableMySQLErrors intentionally doesn't match on number alone).
+func mysqlErrorNumber(err error) uint16 {
+	var merr *mysql.MySQLError
+	if errors.As(err, &merr) {
+		return merr.Number
+	}
+	return 0
+}
+
+// slowChunkLatency is the per-chunk INSERT duration above which
+// adaptiveChunkSize treats the target as struggling and backs off, the
+// same AIMD shape TCP congestion control uses.
+const slowChunkLatency = 2 * time.Second
+
+// adaptiveChunkSize is an AIMD controller for the number of rows
+// executeBatchInsert puts in one multi-row INSERT. A fast chunk nudges
+// the size up a little (by 10% plus one row); a slow chunk or a rejected
+// one (1153) cuts it in half (multiplicative decrease), bounded by
+// cfg.MinChunkSize/MaxChunkSize. One instance is shared for the lifetime
+// of a MySQLSink so the size can actually settle near whatever the
+// target can absorb instead of resetting every batch.
+type adaptiveChunkSize struct {
+	mu  sync.Mutex
+	cur int
+	min int
+	max int
+}
+
+func newAdaptiveChunkSize(cfg Config) *adaptiveChunkSize {
+	cur := 1000
+	if cur < cfg.MinChunkSize {
+		cur = cfg.MinChunkSize
+	}
+	if cur > cfg.MaxChunkSize {
+		cur = cfg.MaxChunkSize
+	}
+	return &adaptiveChunkSize{cur: cur, min: cfg.MinChunkSize, max: cfg.MaxChunkSize}
+}
+
+func (a *adaptiveChunkSize) Size() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.cur
+}
+
+// RecordSuccess nudges the chunk size for next time based on how long
+// this one took: up by 10% if it was fast, halved if it was slow.
+func (a *adaptiveChunkSize) RecordSuccess(elapsed time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if elapsed > slowChunkLatency {
+		a.shrinkLocked()
+		return
+	}
+	a.cur += a.cur/10 + 1
+	if a.cur > a.max {
+		a.cur = a.max
+	}
+}
+
+// Shrink halves the chunk size immediately, for a chunk the target
+// rejected outright rather than merely took too long to process.
+func (a *adaptiveChunkSize) Shrink() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.shrinkLocked()
+}
+
+func (a *adaptiveChunkSize) shrinkLocked() {
+	a.cur /= 2
+	if a.cur < a.min {
+		a.cur = a.min
+	}
+}
+
 func indexOfCol(cols []string, col string) int {
 	for i, c := range cols {
 		if c == col {
@@ -357,7 +711,108 @@ func join(a []string, sep string) string {
 	return res
 }
 
-func streamingLoad(cfg Config, srcDB, tgtDB *sql.DB) error {
+// streamCheckpointTracker persists streamingLoad's PK cursor as batches
+// finish inserting. streamingLoad pipelines numInserters workers pulling
+// batches off a shared channel, so batches can finish out of dispatch
+// order; the tracker only advances the persisted cursor as far as the
+// longest contiguous run of completed batch sequence numbers, so a
+// later batch finishing first never gets checkpointed ahead of a still
+// in-flight earlier one and leaves a gap on resume.
+type streamCheckpointTracker struct {
+	mu       sync.Mutex
+	tgtDB    *sql.DB
+	key      string
+	every    int64
+	nextWant int64
+	pending  map[int64][]interface{}
+}
+
+func newStreamCheckpointTracker(tgtDB *sql.DB, key string, startSeq int64, every int) *streamCheckpointTracker {
+	if every < 1 {
+		every = 1
+	}
+	return &streamCheckpointTracker{
+		tgtDB:    tgtDB,
+		key:      key,
+		every:    int64(every),
+		nextWant: startSeq + 1,
+		pending:  make(map[int64][]interface{}),
+	}
+}
+
+// complete records batch seq as successfully inserted with the PK cursor
+// as of its last row, and persists that cursor once it extends the
+// contiguous completed run up to a checkpoint boundary.
+func (t *streamCheckpointTracker) complete(seq int64, cursor []interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending[seq] = cursor
+	lastSeq := int64(-1)
+	var lastCursor []interface{}
+	for {
+		c, ok := t.pending[t.nextWant]
+		if !ok {
+			break
+		}
+		lastSeq = t.nextWant
+		lastCursor = c
+		delete(t.pending, t.nextWant)
+		t.nextWant++
+	}
+	if lastSeq < 0 || lastSeq%t.every != 0 {
+		return nil
+	}
+
+	cursorJSON, err := json.Marshal(lastCursor)
+	if err != nil {
+		return fmt.Errorf("encode streaming cursor: %v", err)
+	}
+	return WriteStreamingCursor(t.tgtDB, t.key, lastSeq, string(cursorJSON))
+}
+
+// resumeStreamingCursor looks for a PK cursor a prior streamingLoad run
+// flushed for this table. If one exists, it's returned so the load can
+// pick up from the next row instead of restarting at 0. If none exists
+// but the target table already holds rows - a run from before this tool
+// tracked cursors, or one that crashed before its first checkpoint -
+// the table is truncated first: streamingLoad uses plain INSERT, not
+// INSERT IGNORE/UPSERT, so starting over against a non-empty table would
+// just fail on the first duplicate PK instead of actually resuming.
+func resumeStreamingCursor(tgtDB *sql.DB, cfg Config) (cursor []interface{}, seq int64, err error) {
+	key := keyFor(cfg)
+	seq, cursorJSON, err := ReadStreamingCursor(tgtDB, key)
+	if err == nil {
+		var raw []string
+		if jerr := json.Unmarshal([]byte(cursorJSON), &raw); jerr != nil {
+			return nil, 0, fmt.Errorf("decode streaming cursor: %v", jerr)
+		}
+		cursor = make([]interface{}, len(raw))
+		for i, v := range raw {
+			cursor[i] = v
+		}
+		log.Printf("Resuming streaming load for %s from batch %d (cursor %s)\n", key, seq, cursorJSON)
+		return cursor, seq, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, 0, fmt.Errorf("read streaming cursor: %v", err)
+	}
+
+	var count int64
+	q := fmt.Sprintf("SELECT COUNT(*) FROM `%s`.`%s`", cfg.TgtDB, cfg.TargetTable)
+	if qerr := tgtDB.QueryRow(q).Scan(&count); qerr != nil {
+		return nil, 0, fmt.Errorf("check target row count: %v", qerr)
+	}
+	if count > 0 {
+		log.Printf("No streaming cursor for %s but target table has %d existing rows; truncating for a clean streaming load\n", key, count)
+		if _, terr := tgtDB.Exec(fmt.Sprintf("TRUNCATE TABLE `%s`.`%s`", cfg.TgtDB, cfg.TargetTable)); terr != nil {
+			return nil, 0, fmt.Errorf("truncate target table before streaming load: %v", terr)
+		}
+	}
+	return nil, 0, nil
+}
+
+func streamingLoad(ctx context.Context, cfg Config, srcDB, tgtDB *sql.DB, sink TargetSink, throttler *Throttler, gov *concurrencyGovernor) error {
 	// Use target database
 	if _, err := tgtDB.Exec(fmt.Sprintf("USE `%s`", cfg.TgtDB)); err != nil {
 		return err
@@ -401,25 +856,50 @@ func streamingLoad(cfg Config, srcDB, tgtDB *sql.DB) error {
 		batchSize = 1000
 	}
 	
+	if err := EnsureStreamingProgressTable(tgtDB); err != nil {
+		return fmt.Errorf("ensure streaming progress table: %v", err)
+	}
+	lastPKValues, batchSeq, err := resumeStreamingCursor(tgtDB, cfg)
+	if err != nil {
+		return fmt.Errorf("resume streaming cursor: %v", err)
+	}
+	checkpoint := newStreamCheckpointTracker(tgtDB, keyFor(cfg), batchSeq, cfg.CheckpointEvery)
+	budget := gov.forTable(cfg)
+
 	log.Printf("Starting cursor-based streaming load (optimized for large tables) with batch size: %d\n", batchSize)
-	
+
 	totalCount := 0
-	var lastPKValues []interface{} // Cursor position - last primary key values seen
-	var cols []string               // Column names - captured from first query
-	
+	var cols []string // Column names - captured from first query
+
 	// Use multiple goroutines to parallelize INSERT operations
 	// While one batch is being inserted, the next batch is being fetched
 	const numInserters = 4
 	batchChan := make(chan batchInsertJob, numInserters*2) // Buffer for pipelining
 	errorChan := make(chan error, numInserters)
 	var insertWg sync.WaitGroup
-	
+
+	// abort closes batchChan and waits for the inserter goroutines to drain
+	// and exit before returning err, so a shutdown, critical throttle, lost
+	// connection, or query/scan failure mid-loop doesn't leak the pool.
+	abort := func(err error) error {
+		close(batchChan)
+		insertWg.Wait()
+		return err
+	}
+
 	for {
+		if ctx.Err() != nil {
+			return abort(ErrShutdown)
+		}
+		if err := throttler.Wait(); err != nil {
+			return abort(err)
+		}
+
 		// Verify database connection is alive before querying
 		// This prevents "invalid connection" errors on stale connections
 		if err := srcDB.Ping(); err != nil {
 			log.Printf("Warning: source database connection lost, reconnecting...")
-			return fmt.Errorf("connection lost at row %d: %v", totalCount, err)
+			return abort(fmt.Errorf("connection lost at row %d: %v", totalCount, err))
 		}
 		
 		// Build WHERE clause for cursor-based pagination (much faster than OFFSET)
@@ -466,18 +946,18 @@ func streamingLoad(cfg Config, srcDB, tgtDB *sql.DB) error {
 		if err != nil {
 			// Check if it's a connection/timeout error and provide better error message
 			errStr := err.Error()
-			if errStr == "invalid connection" || 
-			   strings.Contains(errStr, "i/o timeout") || 
+			if errStr == "invalid connection" ||
+			   strings.Contains(errStr, "i/o timeout") ||
 			   strings.Contains(errStr, "timeout") {
-				return fmt.Errorf("query failed at row %d: connection timeout (query took too long, BATCH_SIZE=%d may be too large for your database)", totalCount, cfg.BatchSize)
+				return abort(fmt.Errorf("query failed at row %d: connection timeout (query took too long, BATCH_SIZE=%d may be too large for your database)", totalCount, cfg.BatchSize))
 			}
-			return fmt.Errorf("query failed at row %d: %v", totalCount, err)
+			return abort(fmt.Errorf("query failed at row %d: %v", totalCount, err))
 		}
 
 		colsFromQuery, err := rows.Columns()
 		if err != nil {
 			rows.Close()
-			return err
+			return abort(err)
 		}
 		
 		// First iteration: capture column names and start inserter goroutines
@@ -490,10 +970,21 @@ func streamingLoad(cfg Config, srcDB, tgtDB *sql.DB) error {
 				go func(workerID int) {
 					defer insertWg.Done()
 					for job := range batchChan {
-						if err := insertBatchJob(tgtDB, cfg, job); err != nil {
+						release, err := budget.Acquire(ctx, len(job.batchRows))
+						if err != nil {
+							errorChan <- fmt.Errorf("worker %d: acquire concurrency budget: %v", workerID, err)
+							return
+						}
+						err = insertBatchJob(sink, cfg, job)
+						release()
+						if err != nil {
 							errorChan <- fmt.Errorf("worker %d: %v", workerID, err)
 							return
 						}
+						if err := checkpoint.complete(job.seq, job.cursor); err != nil {
+							errorChan <- fmt.Errorf("worker %d: persist streaming checkpoint: %v", workerID, err)
+							return
+						}
 					}
 				}(i)
 			}
@@ -512,7 +1003,7 @@ func streamingLoad(cfg Config, srcDB, tgtDB *sql.DB) error {
 			}
 			if !found {
 				rows.Close()
-				return fmt.Errorf("primary key column %s not found in result set", pkCol)
+				return abort(fmt.Errorf("primary key column %s not found in result set", pkCol))
 			}
 		}
 
@@ -528,7 +1019,7 @@ func streamingLoad(cfg Config, srcDB, tgtDB *sql.DB) error {
 		for rows.Next() {
 			if err := rows.Scan(scanArgs...); err != nil {
 				rows.Close()
-				return fmt.Errorf("scan failed at row %d: %v", totalCount, err)
+				return abort(fmt.Errorf("scan failed at row %d: %v", totalCount, err))
 			}
 
 			args := make([]interface{}, len(values))
@@ -560,18 +1051,19 @@ func streamingLoad(cfg Config, srcDB, tgtDB *sql.DB) error {
 		batchCopy := make([][]interface{}, len(batchRows))
 		copy(batchCopy, batchRows)
 		
+		batchSeq++
 		job := batchInsertJob{
 			cols:      cols,
 			batchRows: batchCopy,
+			seq:       batchSeq,
+			cursor:    lastPKValues,
 		}
-		
+
 		select {
 		case batchChan <- job:
 			// Batch queued successfully
 		case err := <-errorChan:
-			close(batchChan)
-			insertWg.Wait()
-			return fmt.Errorf("insert error at row %d: %v", totalCount, err)
+			return abort(fmt.Errorf("insert error at row %d: %v", totalCount, err))
 		}
 
 		totalCount += batchCount
@@ -622,21 +1114,14 @@ func streamingLoad(cfg Config, srcDB, tgtDB *sql.DB) error {
 	return nil
 }
 
-// insertBatchJob handles the actual database insert with proper error handling
-func insertBatchJob(tgtDB *sql.DB, cfg Config, job batchInsertJob) error {
-	tx, err := tgtDB.Begin()
-	if err != nil {
-		return fmt.Errorf("begin transaction failed: %v", err)
-	}
+// insertBatchJob handles the actual sink write with proper error handling
+func insertBatchJob(sink TargetSink, cfg Config, job batchInsertJob) error {
+	start := time.Now()
+	defer func() { fullLoadBatchDuration.Observe(time.Since(start).Seconds()) }()
 
-	if err := executeBatchInsert(tx, cfg, job.cols, job.batchRows); err != nil {
-		tx.Rollback()
+	if err := sink.WriteBatch(cfg, job.cols, job.batchRows); err != nil {
 		return fmt.Errorf("batch insert failed: %v", err)
 	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit failed: %v", err)
-	}
-	
 	return nil
 }
+
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStreamCheckpointTracker_OutOfOrderStaysPendingUntilContiguous(t *testing.T) {
+	tr := newStreamCheckpointTracker(nil, "t", 0, 1000)
+
+	if err := tr.complete(2, []interface{}{"20"}); err != nil {
+		t.Fatalf("complete(2): %v", err)
+	}
+	if len(tr.pending) != 1 || tr.nextWant != 1 {
+		t.Fatalf("batch 2 should be held pending until batch 1 arrives, got pending=%v nextWant=%d", tr.pending, tr.nextWant)
+	}
+
+	if err := tr.complete(1, []interface{}{"10"}); err != nil {
+		t.Fatalf("complete(1): %v", err)
+	}
+	if len(tr.pending) != 0 || tr.nextWant != 3 {
+		t.Fatalf("contiguous run should drain pending, got pending=%v nextWant=%d", tr.pending, tr.nextWant)
+	}
+}
+
+func TestStreamCheckpointTracker_PersistsOnlyAtCheckpointBoundary(t *testing.T) {
+	tr := newStreamCheckpointTracker(nil, "t", 0, 2)
+
+	// seq 1 completes a contiguous run but isn't a checkpoint boundary for
+	// every=2, so this must return without touching the nil tgtDB.
+	if err := tr.complete(1, []interface{}{"10"}); err != nil {
+		t.Fatalf("complete(1) should not attempt to persist: %v", err)
+	}
+}
+
+func TestAdaptiveChunkSize_GrowsOnFastSuccess(t *testing.T) {
+	a := newAdaptiveChunkSize(Config{MinChunkSize: 100, MaxChunkSize: 5000})
+	start := a.Size()
+	a.RecordSuccess(10 * time.Millisecond)
+	if a.Size() <= start {
+		t.Fatalf("Size() = %d, want greater than %d after a fast chunk", a.Size(), start)
+	}
+}
+
+func TestAdaptiveChunkSize_ShrinksOnSlowSuccess(t *testing.T) {
+	a := newAdaptiveChunkSize(Config{MinChunkSize: 100, MaxChunkSize: 5000})
+	start := a.Size()
+	a.RecordSuccess(slowChunkLatency + time.Second)
+	if a.Size() >= start {
+		t.Fatalf("Size() = %d, want less than %d after a slow chunk", a.Size(), start)
+	}
+}
+
+func TestAdaptiveChunkSize_RespectsMinAndMax(t *testing.T) {
+	a := newAdaptiveChunkSize(Config{MinChunkSize: 100, MaxChunkSize: 150})
+	for i := 0; i < 20; i++ {
+		a.RecordSuccess(time.Millisecond)
+	}
+	if a.Size() > 150 {
+		t.Fatalf("Size() = %d, want capped at MaxChunkSize 150", a.Size())
+	}
+	for i := 0; i < 20; i++ {
+		a.Shrink()
+	}
+	if a.Size() < 100 {
+		t.Fatalf("Size() = %d, want floored at MinChunkSize 100", a.Size())
+	}
+}
+
+func TestMysqlErrorNumber_NonMySQLError(t *testing.T) {
+	if got := mysqlErrorNumber(errors.New("boom")); got != 0 {
+		t.Errorf("mysqlErrorNumber(non-mysql error) = %d, want 0", got)
+	}
+}
+
+func TestRetryableMySQLErrors_Classification(t *testing.T) {
+	for _, num := range []uint16{1213, 1205, 1290, 2006, 2013} {
+		if !retryableMySQLErrors[num] {
+			t.Errorf("error %d should be retryable", num)
+		}
+	}
+	if retryableMySQLErrors[1153] {
+		t.Errorf("1153 (packet too large) should not be in retryableMySQLErrors; it's handled separately via Shrink")
+	}
+}
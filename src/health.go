@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -12,18 +13,21 @@ import (
 
 // Metrics tracks CDC replication statistics
 type Metrics struct {
-	mu                sync.RWMutex
-	StartTime         time.Time
-	LastEventTime     time.Time
-	EventsProcessed   int64
-	InsertsProcessed  int64
-	UpdatesProcessed  int64
-	DeletesProcessed  int64
-	ErrorCount        int64
-	LastError         string
-	LastCheckpoint    string
-	ReplicationLagSec float64
-	Status            string
+	mu                 sync.RWMutex
+	StartTime          time.Time
+	LastEventTime      time.Time
+	EventsProcessed    int64
+	InsertsProcessed   int64
+	UpdatesProcessed   int64
+	DeletesProcessed   int64
+	ErrorCount         int64
+	LastError          string
+	LastCheckpointFile string
+	LastCheckpointPos  uint32
+	ReplicationLagSec  float64
+	Status             string
+	ThrottleReason     string
+	OversizedRowCount  int64
 }
 
 var globalMetrics = &Metrics{
@@ -54,10 +58,11 @@ func (m *Metrics) UpdateError(err string) {
 	m.LastError = err
 }
 
-func (m *Metrics) UpdateCheckpoint(checkpoint string) {
+func (m *Metrics) UpdateCheckpoint(file string, pos uint32) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.LastCheckpoint = checkpoint
+	m.LastCheckpointFile = file
+	m.LastCheckpointPos = pos
 }
 
 func (m *Metrics) UpdateStatus(status string) {
@@ -72,29 +77,86 @@ func (m *Metrics) UpdateReplicationLag(lag float64) {
 	m.ReplicationLagSec = lag
 }
 
+// UpdateThrottleReason records why the pipeline last throttled, so /metrics
+// can surface it to operators. An empty reason means the pipeline is running
+// at full speed.
+func (m *Metrics) UpdateThrottleReason(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ThrottleReason = reason
+}
+
+// UpdateOversizedRowCount increments the count of CDC rows that crossed
+// Config.WarnRowBytes/WarnColumnBytes, so operators can watch for these on
+// /metrics without grepping logs for the structured warning itself.
+func (m *Metrics) UpdateOversizedRowCount() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.OversizedRowCount++
+}
+
 func (m *Metrics) GetSnapshot() map[string]interface{} {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	
+
 	uptime := time.Since(m.StartTime).Seconds()
 	var eventsPerSec float64
 	if uptime > 0 {
 		eventsPerSec = float64(m.EventsProcessed) / uptime
 	}
-	
+
 	return map[string]interface{}{
-		"status":                m.Status,
-		"uptime_seconds":        int64(uptime),
-		"events_processed":      m.EventsProcessed,
-		"inserts_processed":     m.InsertsProcessed,
-		"updates_processed":     m.UpdatesProcessed,
-		"deletes_processed":     m.DeletesProcessed,
-		"events_per_second":     fmt.Sprintf("%.2f", eventsPerSec),
-		"error_count":           m.ErrorCount,
-		"last_error":            m.LastError,
-		"last_checkpoint":       m.LastCheckpoint,
-		"replication_lag_sec":   fmt.Sprintf("%.2f", m.ReplicationLagSec),
-		"last_event_time":       m.LastEventTime.Format(time.RFC3339),
+		"status":               m.Status,
+		"uptime_seconds":       int64(uptime),
+		"events_processed":     m.EventsProcessed,
+		"inserts_processed":    m.InsertsProcessed,
+		"updates_processed":    m.UpdatesProcessed,
+		"deletes_processed":    m.DeletesProcessed,
+		"events_per_second":    fmt.Sprintf("%.2f", eventsPerSec),
+		"error_count":          m.ErrorCount,
+		"last_error":           m.LastError,
+		"last_checkpoint_file": m.LastCheckpointFile,
+		"last_checkpoint_pos":  m.LastCheckpointPos,
+		"replication_lag_sec":  fmt.Sprintf("%.2f", m.ReplicationLagSec),
+		"last_event_time":      m.LastEventTime.Format(time.RFC3339),
+		"throttle_reason":      m.ThrottleReason,
+		"oversized_row_count":  m.OversizedRowCount,
+	}
+}
+
+// MetricsSnapshot is a typed copy of Metrics for callers (like the
+// Prometheus exporter) that need raw numeric values rather than the
+// pre-formatted strings in GetSnapshot's map.
+type MetricsSnapshot struct {
+	StartTime          time.Time
+	EventsProcessed    int64
+	InsertsProcessed   int64
+	UpdatesProcessed   int64
+	DeletesProcessed   int64
+	ErrorCount         int64
+	LastCheckpointFile string
+	LastCheckpointPos  uint32
+	ReplicationLagSec  float64
+	OversizedRowCount  int64
+}
+
+// GetSnapshotTyped returns the same data as GetSnapshot without formatting
+// it into strings, for exporters that need to do their own formatting.
+func (m *Metrics) GetSnapshotTyped() MetricsSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return MetricsSnapshot{
+		StartTime:          m.StartTime,
+		EventsProcessed:    m.EventsProcessed,
+		InsertsProcessed:   m.InsertsProcessed,
+		UpdatesProcessed:   m.UpdatesProcessed,
+		DeletesProcessed:   m.DeletesProcessed,
+		ErrorCount:         m.ErrorCount,
+		LastCheckpointFile: m.LastCheckpointFile,
+		LastCheckpointPos:  m.LastCheckpointPos,
+		ReplicationLagSec:  m.ReplicationLagSec,
+		OversizedRowCount:  m.OversizedRowCount,
 	}
 }
 
@@ -107,24 +169,43 @@ type HealthCheck struct {
 	Metrics  map[string]interface{} `json:"metrics,omitempty"`
 }
 
-// StartHealthServer starts the HTTP health check server
-func StartHealthServer(port int, srcDB, tgtDB *sql.DB, cfg Config) {
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+// StartHealthServer starts the HTTP health check server. It shuts down
+// gracefully when ctx is cancelled, so /ready can flip to 503 and in-flight
+// requests can drain before the process exits.
+func StartHealthServer(ctx context.Context, port int, srcDB, tgtDB *sql.DB, cfg Config) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		handleHealthCheck(w, r, srcDB, tgtDB, cfg)
 	})
-	
-	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
 		handleMetrics(w, r)
 	})
-	
-	http.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+
+	mux.HandleFunc("/metrics/prometheus", func(w http.ResponseWriter, r *http.Request) {
+		handlePrometheusMetrics(w, r)
+	})
+
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
 		handleReadiness(w, r, srcDB, tgtDB)
 	})
-	
+
 	addr := fmt.Sprintf(":%d", port)
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Health server shutdown error: %v", err)
+		}
+	}()
+
 	log.Printf("Health check server listening on %s", addr)
-	
-	if err := http.ListenAndServe(addr, nil); err != nil {
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Printf("Health server error: %v", err)
 	}
 }
@@ -139,56 +220,73 @@ func handleHealthCheck(w http.ResponseWriter, r *http.Request, srcDB, tgtDB *sql
 			"target": fmt.Sprintf("%s.%s", cfg.TgtDB, cfg.TargetTable),
 		},
 	}
-	
+
+	// Surface the parsed (not raw) DSN host:port so the health endpoint never
+	// leaks credentials embedded in the DSN.
+	if srcInfo, err := parseDSN(cfg.SrcDSN); err == nil {
+		health.Database["source_host"] = fmt.Sprintf("%s:%d", srcInfo.Host, srcInfo.Port)
+	}
+	if tgtInfo, err := parseDSN(cfg.TgtDSN); err == nil {
+		health.Database["target_host"] = fmt.Sprintf("%s:%d", tgtInfo.Host, tgtInfo.Port)
+	}
+
 	// Check source database connection
 	if err := srcDB.Ping(); err != nil {
 		health.Status = "unhealthy"
 		health.Database["source_error"] = err.Error()
 		w.WriteHeader(http.StatusServiceUnavailable)
 	}
-	
+
 	// Check target database connection
 	if err := tgtDB.Ping(); err != nil {
 		health.Status = "unhealthy"
 		health.Database["target_error"] = err.Error()
 		w.WriteHeader(http.StatusServiceUnavailable)
 	}
-	
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(health)
 }
 
 func handleMetrics(w http.ResponseWriter, r *http.Request) {
 	metrics := globalMetrics.GetSnapshot()
-	
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(metrics)
 }
 
 func handleReadiness(w http.ResponseWriter, r *http.Request, srcDB, tgtDB *sql.DB) {
+	// A shutdown signal flips this immediately, before any draining happens,
+	// so the load balancer stops routing new traffic here right away.
+	if isShuttingDown() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "not ready", "reason": "shutting down"})
+		return
+	}
+
 	// Check if databases are ready
 	if err := srcDB.Ping(); err != nil {
 		w.WriteHeader(http.StatusServiceUnavailable)
 		json.NewEncoder(w).Encode(map[string]string{"status": "not ready", "reason": "source db unavailable"})
 		return
 	}
-	
+
 	if err := tgtDB.Ping(); err != nil {
 		w.WriteHeader(http.StatusServiceUnavailable)
 		json.NewEncoder(w).Encode(map[string]string{"status": "not ready", "reason": "target db unavailable"})
 		return
 	}
-	
+
 	// Check if CDC is actively processing
 	snapshot := globalMetrics.GetSnapshot()
 	status := snapshot["status"].(string)
-	
+
 	if status == "initializing" || status == "error" {
 		w.WriteHeader(http.StatusServiceUnavailable)
 		json.NewEncoder(w).Encode(map[string]string{"status": "not ready", "cdc_status": status})
 		return
 	}
-	
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
 }
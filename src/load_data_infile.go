@@ -0,0 +1,121 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// readerHandleSeq gives each LOAD DATA LOCAL INFILE call its own registered
+// reader name, so concurrent full-load workers don't clobber each other's
+// handler registration.
+var readerHandleSeq int64
+
+// ensureLocalInfileEnabled verifies the target server accepts LOAD DATA
+// LOCAL INFILE, enabling it via SET GLOBAL when it's currently off. The
+// local_infile system variable is GLOBAL-scope only (MySQL rejects SET
+// SESSION local_infile), so this has to run once up front rather than per
+// connection; every subsequent loadDataInfile call on this server would
+// otherwise fail with error 1148 the first time a batch runs.
+func ensureLocalInfileEnabled(db *sql.DB) error {
+	var name, value string
+	if err := db.QueryRow("SHOW VARIABLES LIKE 'local_infile'").Scan(&name, &value); err != nil {
+		return fmt.Errorf("check local_infile: %v", err)
+	}
+	if strings.EqualFold(value, "ON") || value == "1" {
+		return nil
+	}
+	if _, err := db.Exec("SET GLOBAL local_infile = 1"); err != nil {
+		return fmt.Errorf("LOAD_METHOD=load_data_infile requires local_infile=1 on the target server, and enabling it failed (likely missing SUPER/SYSTEM_VARIABLES_ADMIN privilege): %v", err)
+	}
+	log.Println("Enabled local_infile=1 on the target server (was off) for LOAD_METHOD=load_data_infile; this is a server-global, persistent-until-restart setting, not scoped to this tool's connection")
+	return nil
+}
+
+// loadDataInfile bulk-loads batchRows into cfg.TargetTable via LOAD DATA
+// LOCAL INFILE, streaming TSV-encoded rows through an io.Pipe registered as
+// a mysql.RegisterReaderHandler reader rather than writing a temp file. This
+// is the cfg.LoadMethod == "load_data_infile" alternative to the extended
+// INSERT path in executeBatchInsert, used by the same callers (loadRange,
+// copyKeyChunk, insertBatchJob) through MySQLSink.WriteBatch.
+func loadDataInfile(tx *sql.Tx, cfg Config, cols []string, batchRows [][]interface{}) error {
+	if len(batchRows) == 0 {
+		return nil
+	}
+
+	handle := fmt.Sprintf("full_load_%d", atomic.AddInt64(&readerHandleSeq, 1))
+	pr, pw := io.Pipe()
+	mysql.RegisterReaderHandler(handle, func() io.Reader { return pr })
+	defer mysql.DeregisterReaderHandler(handle)
+
+	writeErr := make(chan error, 1)
+	go func() {
+		writeErr <- writeTSVRows(pw, batchRows)
+		pw.Close()
+	}()
+
+	var colNames []string
+	for _, c := range cols {
+		colNames = append(colNames, fmt.Sprintf("`%s`", c))
+	}
+	q := fmt.Sprintf(
+		"LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE `%s`.`%s` FIELDS TERMINATED BY '\\t' ESCAPED BY '\\\\' LINES TERMINATED BY '\\n' (%s)",
+		handle, cfg.TgtDB, cfg.TargetTable, strings.Join(colNames, ", "),
+	)
+	if _, err := tx.Exec(q); err != nil {
+		pr.Close()
+		<-writeErr
+		return fmt.Errorf("load data local infile: %v", err)
+	}
+
+	if err := <-writeErr; err != nil {
+		return fmt.Errorf("encode load data infile rows: %v", err)
+	}
+	return nil
+}
+
+// writeTSVRows encodes batchRows as tab-separated, newline-terminated rows,
+// escaping the bytes that would otherwise be misread as field/line/escape
+// characters or turn a literal backslash into an escape sequence, per
+// MySQL's LOAD DATA field-escaping rules. A nil value is written as MySQL's
+// NULL marker, \N, not an empty field, so it round-trips as NULL rather than
+// an empty string.
+func writeTSVRows(w io.Writer, batchRows [][]interface{}) error {
+	var sb strings.Builder
+	for _, row := range batchRows {
+		sb.Reset()
+		for i, v := range row {
+			if i > 0 {
+				sb.WriteByte('\t')
+			}
+			if v == nil {
+				sb.WriteString(`\N`)
+				continue
+			}
+			sb.WriteString(escapeInfileField(fmt.Sprintf("%v", v)))
+		}
+		sb.WriteByte('\n')
+		if _, err := w.Write([]byte(sb.String())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeInfileField backslash-escapes the characters LOAD DATA treats
+// specially when FIELDS TERMINATED BY '\t' and LINES TERMINATED BY '\n' are
+// in effect: the field/line delimiters themselves and the escape character.
+func escapeInfileField(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		"\t", `\t`,
+		"\n", `\n`,
+		"\r", `\r`,
+	)
+	return replacer.Replace(s)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteTSVRows_BasicAndNull(t *testing.T) {
+	var sb strings.Builder
+	rows := [][]interface{}{
+		{"1", "alice", nil},
+		{"2", "bob", "active"},
+	}
+	if err := writeTSVRows(&sb, rows); err != nil {
+		t.Fatalf("writeTSVRows returned error: %v", err)
+	}
+	want := "1\talice\t\\N\n2\tbob\tactive\n"
+	if sb.String() != want {
+		t.Errorf("writeTSVRows = %q, want %q", sb.String(), want)
+	}
+}
+
+func TestWriteTSVRows_EscapesSpecialChars(t *testing.T) {
+	var sb strings.Builder
+	rows := [][]interface{}{
+		{"a\tb", "c\nd", `e\f`},
+	}
+	if err := writeTSVRows(&sb, rows); err != nil {
+		t.Fatalf("writeTSVRows returned error: %v", err)
+	}
+	want := "a\\tb\tc\\nd\te\\\\f\n"
+	if sb.String() != want {
+		t.Errorf("writeTSVRows = %q, want %q", sb.String(), want)
+	}
+}
+
+func TestEscapeInfileField(t *testing.T) {
+	cases := map[string]string{
+		"plain": "plain",
+		"a\tb":  `a\tb`,
+		"a\nb":  `a\nb`,
+		"a\rb":  `a\rb`,
+		`a\b`:   `a\\b`,
+	}
+	for in, want := range cases {
+		if got := escapeInfileField(in); got != want {
+			t.Errorf("escapeInfileField(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -10,29 +10,57 @@ func main() {
 	cfg := LoadConfig()
 	log.Printf("Starting mysql-cdc-go single-table for %s.%s -> %s.%s\n", cfg.SrcDB, cfg.SrcTable, cfg.TgtDB, cfg.TargetTable)
 
+	sc := NewShutdownCoordinator(cfg.LameDuckSeconds)
+
 	srcDB, err := OpenDB(cfg.SrcDSN)
 	if err != nil {
 		log.Fatalln("open src db:", err)
 	}
-	tgtDB, err := OpenDB(cfg.TgtDSN)
+	// tgtDB itself stays a MySQL connection regardless of sink: checkpoints
+	// and full-load progress tracking are bookkeeping tables this tool owns
+	// and always keeps in MySQL, even when the replicated data is going
+	// elsewhere. A postgres:// or file:// TGT_DSN therefore still needs a
+	// reachable MySQL server for that bookkeeping - CHECKPOINT_DSN supplies
+	// it; only the row/schema writes for the table being replicated go
+	// through sink. The postgres and file sinks open TGT_DSN themselves;
+	// the mysql sink writes through tgtDB. Opening tgtDB from TGT_DSN unconditionally here, before sink
+	// ever got a chance to interpret its scheme, used to hard-fail startup
+	// for any non-mysql TGT_DSN.
+	checkpointDSN := cfg.CheckpointDSN
+	if checkpointDSN == "" {
+		checkpointDSN = cfg.TgtDSN
+	}
+	tgtDB, err := OpenDB(checkpointDSN)
 	if err != nil {
-		log.Fatalln("open tgt db:", err)
+		log.Fatalln("open checkpoint db:", err)
 	}
 	defer srcDB.Close()
 	defer tgtDB.Close()
 
+	sink, err := NewTargetSink(cfg, tgtDB)
+	if err != nil {
+		log.Fatalln("create target sink:", err)
+	}
+	defer sink.Close()
+
+	go StartHealthServer(sc.Context(), cfg.HealthPort, srcDB, tgtDB, cfg)
+
 	// Check if we can resume from checkpoint (skip full load)
 	var file string
 	var pos uint32
-	
+	var gtidSet, flavor string
+
 	checkpointTable := fmt.Sprintf("`%s`.`%s`", cfg.TgtDB, cfg.CheckpointTable)
 	if err = EnsureCheckpointTable(tgtDB, checkpointTable); err != nil {
 		log.Fatalln("ensure checkpoint:", err)
 	}
-	
-	file, pos, err = ReadCheckpoint(tgtDB, checkpointTable, keyFor(cfg))
+
+	file, pos, gtidSet, flavor, err = ReadCheckpoint(tgtDB, checkpointTable, keyFor(cfg))
 	if err == nil && file != "" && pos > 0 {
-		// Check if target table exists and has data
+		// Check if target table exists and has data. This check only makes
+		// sense for the mysql sink; a postgres or file target's full-load
+		// state lives outside tgtDB, so non-mysql TGT_DSNs currently always
+		// redo the full load on restart rather than trusting this checkpoint.
 		var count int64
 		checkQuery := fmt.Sprintf("SELECT COUNT(*) FROM `%s`.`%s` LIMIT 1", cfg.TgtDB, cfg.TargetTable)
 		err = tgtDB.QueryRow(checkQuery).Scan(&count)
@@ -57,11 +85,17 @@ func main() {
 			log.Println("Dropping target table and progress tables before retry")
 			tgtDB.Exec(fmt.Sprintf("DROP TABLE IF EXISTS `%s`.`%s`", cfg.TgtDB, cfg.TargetTable))
 			tgtDB.Exec("DROP TABLE IF EXISTS full_load_progress")
+			tgtDB.Exec("DROP TABLE IF EXISTS streaming_progress")
+			tgtDB.Exec("DROP TABLE IF EXISTS full_load_checksum")
 		}
-		file, pos, err = runFullLoad(cfg, srcDB, tgtDB)
+		file, pos, err = runFullLoad(sc.Context(), cfg, srcDB, tgtDB, sink)
 		if err == nil {
 			break
 		}
+		if err == ErrShutdown {
+			log.Println("Shutdown requested during full load, exiting")
+			return
+		}
 		log.Println("Full-load attempt failed:", err)
 		if attempt == cfg.FullloadRetries {
 			log.Fatalln("Full-load failed after retries")
@@ -76,6 +110,14 @@ func main() {
 		time.Sleep(time.Duration(sleep) * time.Second)
 	}
 
+	// runFullLoad persists its own checkpoint including GTID info; re-read
+	// it so CDC can prefer GTID resumption when available.
+	if file != "" {
+		if f, p, g, fl, rerr := ReadCheckpoint(tgtDB, checkpointTable, keyFor(cfg)); rerr == nil {
+			file, pos, gtidSet, flavor = f, p, g, fl
+		}
+	}
+
 StartCDC:
 	// Use target database for CDC
 	if _, err := tgtDB.Exec(fmt.Sprintf("USE `%s`", cfg.TgtDB)); err != nil {
@@ -86,9 +128,13 @@ StartCDC:
 	if file == "" || pos == 0 {
 		log.Fatalln("No binlog position available after full load")
 	}
-	log.Printf("Starting CDC from %s:%d\n", file, pos)
+	if gtidSet != "" {
+		log.Printf("Starting CDC from %s:%d (GTID set available, will prefer GTID resumption)\n", file, pos)
+	} else {
+		log.Printf("Starting CDC from %s:%d\n", file, pos)
+	}
 
-	if err := runCDC(cfg, srcDB, tgtDB, file, pos); err != nil {
+	if err := runCDC(sc, cfg, srcDB, tgtDB, file, pos, gtidSet, flavor); err != nil {
 		log.Fatalln("cdc failed:", err)
 	}
 }
@@ -3,8 +3,17 @@ package main
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 )
 
+// sqlExecutor is satisfied by both *sql.DB and *sql.Tx, so WriteCheckpoint
+// can run standalone (autocommit) or as part of a larger transaction - the
+// latter is how the CDC apply loop commits a checkpoint atomically with the
+// row DML it covers.
+type sqlExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
 func EnsureCheckpointTable(db *sql.DB, table string) error {
 	q := fmt.Sprintf(`
 CREATE TABLE IF NOT EXISTS %s (
@@ -13,26 +22,50 @@ CREATE TABLE IF NOT EXISTS %s (
   binlog_pos BIGINT,
   updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 )`, table)
-	_, err := db.Exec(q)
-	return err
+	if _, err := db.Exec(q); err != nil {
+		return err
+	}
+
+	// Add GTID columns for checkpoint tables created before GTID support
+	// existed. MySQL returns error 1060 (duplicate column) if they're
+	// already there; ignore that so this stays idempotent across restarts.
+	for _, alter := range []string{
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN gtid_set TEXT", table),
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN flavor VARCHAR(16)", table),
+	} {
+		if _, err := db.Exec(alter); err != nil && !strings.Contains(err.Error(), "Duplicate column") {
+			return fmt.Errorf("adding GTID checkpoint columns: %v", err)
+		}
+	}
+	return nil
 }
 
-func WriteCheckpoint(db *sql.DB, table, key, file string, pos uint32) error {
-	q := fmt.Sprintf(`INSERT INTO %s (id, binlog_file, binlog_pos) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE binlog_file=VALUES(binlog_file), binlog_pos=VALUES(binlog_pos)`, table)
-	_, err := db.Exec(q, key, file, pos)
+// WriteCheckpoint persists the binlog file/pos alongside the GTID set and
+// flavor (if known), so a restart can resume via GTID when the source
+// supports it. gtidSet/flavor may be empty when GTID mode is off.
+//
+// WriteCheckpoint does not itself call globalMetrics.UpdateCheckpoint: when
+// db is a *sql.Tx (the CDC apply loop's atomic commit-with-checkpoint path),
+// a successful Exec here isn't durable until the caller's tx.Commit also
+// succeeds, so the metrics update has to happen at the call site, after
+// that commit - not here.
+func WriteCheckpoint(db sqlExecutor, table, key, file string, pos uint32, gtidSet, flavor string) error {
+	q := fmt.Sprintf(`INSERT INTO %s (id, binlog_file, binlog_pos, gtid_set, flavor) VALUES (?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE binlog_file=VALUES(binlog_file), binlog_pos=VALUES(binlog_pos), gtid_set=VALUES(gtid_set), flavor=VALUES(flavor)`, table)
+	_, err := db.Exec(q, key, file, pos, gtidSet, flavor)
 	return err
 }
 
-func ReadCheckpoint(db *sql.DB, table, key string) (string, uint32, error) {
-	q := fmt.Sprintf(`SELECT binlog_file, binlog_pos FROM %s WHERE id = ?`, table)
+// ReadCheckpoint returns the last persisted binlog position plus the GTID
+// set/flavor recorded alongside it, if any.
+func ReadCheckpoint(db *sql.DB, table, key string) (file string, pos uint32, gtidSet string, flavor string, err error) {
+	q := fmt.Sprintf(`SELECT binlog_file, binlog_pos, COALESCE(gtid_set, ''), COALESCE(flavor, '') FROM %s WHERE id = ?`, table)
 	row := db.QueryRow(q, key)
-	var file string
-	var pos uint32
-	err := row.Scan(&file, &pos)
+	err = row.Scan(&file, &pos, &gtidSet, &flavor)
 	if err != nil {
-		return "", 0, err
+		return "", 0, "", "", err
 	}
-	return file, pos, nil
+	return file, pos, gtidSet, flavor, nil
 }
 
 // full_load_progress to track range completion
@@ -46,8 +79,24 @@ CREATE TABLE IF NOT EXISTS full_load_progress (
   updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   PRIMARY KEY (table_key, range_start)
 )`
-	_, err := db.Exec(q)
-	return err
+	if _, err := db.Exec(q); err != nil {
+		return err
+	}
+
+	// Add columns for key-based (unique-index) chunking, used when the
+	// source table has no single integer PK. range_start/range_end still
+	// carry a chunk sequence number in that case; the serialized key tuples
+	// (JSON arrays) live here instead. MySQL returns error 1060 (duplicate
+	// column) if they're already there; ignore that so this stays idempotent.
+	for _, alter := range []string{
+		"ALTER TABLE full_load_progress ADD COLUMN range_key_low VARCHAR(1024)",
+		"ALTER TABLE full_load_progress ADD COLUMN range_key_high VARCHAR(1024)",
+	} {
+		if _, err := db.Exec(alter); err != nil && !strings.Contains(err.Error(), "Duplicate column") {
+			return fmt.Errorf("adding key-chunking progress columns: %v", err)
+		}
+	}
+	return nil
 }
 
 func MarkRangeDone(db *sql.DB, key string, start, end int64) error {
@@ -73,3 +122,157 @@ func GetDoneRanges(db *sql.DB, key string) ([][2]int64, error) {
 	}
 	return res, nil
 }
+
+// MarkRangesPlanned records the row-count-balanced ranges
+// buildStatisticalRanges produced for key, before any of them have been
+// loaded, with status 'planned'. A later MarkRangeDone call updates the
+// same (table_key, range_start) row to status 'done', so the row count
+// stays one-per-range rather than doubling up.
+func MarkRangesPlanned(db *sql.DB, key string, ranges [][2]int64) error {
+	for _, r := range ranges {
+		q := `INSERT INTO full_load_progress (table_key, range_start, range_end, status) VALUES (?, ?, ?, 'planned') ON DUPLICATE KEY UPDATE range_end = VALUES(range_end)`
+		if _, err := db.Exec(q, key, r[0], r[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPlannedRanges returns every integer-PK range (planned or done)
+// previously recorded for key, in range_start order, so a resumed run
+// reuses the exact boundaries a prior run's buildStatisticalRanges chose
+// instead of recomputing them against data that may have since changed.
+// Returns an empty slice, not an error, when nothing has been planned yet.
+func GetPlannedRanges(db *sql.DB, key string) ([][2]int64, error) {
+	q := `SELECT range_start, range_end FROM full_load_progress WHERE table_key = ? AND (range_key_low IS NULL OR range_key_low = '') ORDER BY range_start`
+	rows, err := db.Query(q, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res [][2]int64
+	for rows.Next() {
+		var s, e int64
+		if err := rows.Scan(&s, &e); err != nil {
+			return nil, err
+		}
+		res = append(res, [2]int64{s, e})
+	}
+	return res, rows.Err()
+}
+
+// EnsureStreamingProgressTable creates the sibling progress table streaming
+// loads (streamingLoad) use to persist their PK cursor. It's separate from
+// full_load_progress because a streaming load has no fixed set of ranges to
+// mark done up front - just one advancing cursor per table_key - and giving
+// it its own table keeps that single-row-per-load shape out of the
+// range-oriented one.
+func EnsureStreamingProgressTable(db *sql.DB) error {
+	q := `
+CREATE TABLE IF NOT EXISTS streaming_progress (
+  table_key VARCHAR(255) PRIMARY KEY,
+  batch_seq BIGINT NOT NULL,
+  pk_cursor VARCHAR(1024) NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+)`
+	_, err := db.Exec(q)
+	return err
+}
+
+// WriteStreamingCursor persists the last flushed PK cursor (JSON-encoded,
+// supporting composite keys) for a streaming load, along with its
+// monotonically increasing batch_seq. The update is guarded so an
+// out-of-order write (e.g. from a racing caller) can never regress an
+// already-persisted, further-along cursor.
+func WriteStreamingCursor(db *sql.DB, key string, seq int64, pkCursorJSON string) error {
+	q := `INSERT INTO streaming_progress (table_key, batch_seq, pk_cursor) VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE
+  pk_cursor = IF(VALUES(batch_seq) > batch_seq, VALUES(pk_cursor), pk_cursor),
+  batch_seq = IF(VALUES(batch_seq) > batch_seq, VALUES(batch_seq), batch_seq)`
+	_, err := db.Exec(q, key, seq, pkCursorJSON)
+	return err
+}
+
+// ReadStreamingCursor returns the last persisted PK cursor for a streaming
+// load, if any. Callers should treat sql.ErrNoRows as "no prior cursor",
+// not a failure.
+func ReadStreamingCursor(db *sql.DB, key string) (seq int64, pkCursorJSON string, err error) {
+	q := `SELECT batch_seq, pk_cursor FROM streaming_progress WHERE table_key = ?`
+	err = db.QueryRow(q, key).Scan(&seq, &pkCursorJSON)
+	return seq, pkCursorJSON, err
+}
+
+// EnsureChecksumTable creates the progress table VerifyTable uses to record
+// per-range checksum verification results, so a resumed run only re-checks
+// ranges that never finished - the checksum analogue of full_load_progress.
+func EnsureChecksumTable(db *sql.DB) error {
+	q := `
+CREATE TABLE IF NOT EXISTS full_load_checksum (
+  table_key VARCHAR(255),
+  range_start BIGINT,
+  range_end BIGINT,
+  status VARCHAR(32),
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (table_key, range_start)
+)`
+	_, err := db.Exec(q)
+	return err
+}
+
+func MarkChecksumRangeDone(db *sql.DB, key string, start, end int64) error {
+	q := `INSERT INTO full_load_checksum (table_key, range_start, range_end, status) VALUES (?, ?, ?, 'done') ON DUPLICATE KEY UPDATE range_end = VALUES(range_end), status='done'`
+	_, err := db.Exec(q, key, start, end)
+	return err
+}
+
+func GetDoneChecksumRanges(db *sql.DB, key string) ([][2]int64, error) {
+	q := `SELECT range_start, range_end FROM full_load_checksum WHERE table_key = ? AND status='done' ORDER BY range_start`
+	rows, err := db.Query(q, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res [][2]int64
+	for rows.Next() {
+		var s, e int64
+		if err := rows.Scan(&s, &e); err != nil {
+			return nil, err
+		}
+		res = append(res, [2]int64{s, e})
+	}
+	return res, nil
+}
+
+// MarkKeyChunkDone records completion of one chunk of a unique-key-based
+// load, identified by a monotonic sequence number rather than a numeric
+// range. low/high are JSON-encoded key tuples (e.g. `["a","1"]`), so
+// resumption works for composite or non-integer unique keys.
+func MarkKeyChunkDone(db *sql.DB, key string, seq int64, low, high string) error {
+	q := `INSERT INTO full_load_progress (table_key, range_start, range_end, status, range_key_low, range_key_high) VALUES (?, ?, ?, 'done', ?, ?)
+ON DUPLICATE KEY UPDATE range_end=VALUES(range_end), status='done', range_key_low=VALUES(range_key_low), range_key_high=VALUES(range_key_high)`
+	_, err := db.Exec(q, key, seq, seq, low, high)
+	return err
+}
+
+// GetDoneKeyChunks returns the JSON-encoded (low, high) key tuples of every
+// completed chunk for a key-based load, ordered by chunk sequence number, so
+// a resumed run can skip every chunk already listed.
+func GetDoneKeyChunks(db *sql.DB, key string) ([][2]string, error) {
+	q := `SELECT range_key_low, range_key_high FROM full_load_progress
+WHERE table_key = ? AND status='done' AND range_key_high IS NOT NULL AND range_key_high != ''
+ORDER BY range_start`
+	rows, err := db.Query(q, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res [][2]string
+	for rows.Next() {
+		var low, high string
+		if err := rows.Scan(&low, &high); err != nil {
+			return nil, err
+		}
+		res = append(res, [2]string{low, high})
+	}
+	return res, nil
+}
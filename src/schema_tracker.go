@@ -0,0 +1,349 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ColumnInfo describes one column of a tracked table, enough for the row
+// decoder to match binlog row values by name instead of ordinal position.
+type ColumnInfo struct {
+	Name     string
+	DataType string
+	Charset  string
+}
+
+// TableSchema is the in-memory representation of a tracked table's current
+// columns, refreshed whenever a relevant DDL event is applied.
+type TableSchema struct {
+	Schema  string
+	Table   string
+	Columns []ColumnInfo
+	PKCols  []string
+}
+
+// ColumnNames returns the tracked column names in ordinal order, for
+// building INSERT/UPDATE/DELETE statements without re-querying
+// information_schema on every row event.
+func (ts *TableSchema) ColumnNames() []string {
+	names := make([]string, len(ts.Columns))
+	for i, c := range ts.Columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// ddlTranslator rewrites a DDL statement captured against the source
+// schema/table into one that targets the (possibly renamed) target
+// schema/table, the same rewrite CopyTableSchema applies to CREATE TABLE.
+// It reports relevant=false when the statement doesn't touch the tracked
+// table at all, so the tracker can ignore it.
+type ddlTranslator func(srcSchema, srcTable, query string) (translated string, relevant bool, err error)
+
+// SchemaTracker parses DDL events for the tracked schema/table off the
+// binlog stream, keeps an in-memory TableSchema up to date, mirrors the DDL
+// onto the target database, and persists its state so it survives restarts.
+type SchemaTracker struct {
+	mu         sync.RWMutex
+	cfg        Config
+	tgtDB      *sql.DB
+	translate  ddlTranslator
+	tables     map[string]*TableSchema // keyed by "schema.table" (source names)
+}
+
+// NewSchemaTracker builds a tracker seeded with the tracked table's current
+// column list, loading persisted state if a prior run left any, otherwise
+// reading the live column list from the target database (already copied
+// there by CopyTableSchema).
+func NewSchemaTracker(cfg Config, tgtDB *sql.DB) (*SchemaTracker, error) {
+	st := &SchemaTracker{
+		cfg:       cfg,
+		tgtDB:     tgtDB,
+		translate: defaultDDLTranslator(cfg),
+		tables:    make(map[string]*TableSchema),
+	}
+
+	if err := EnsureSchemaStateTable(tgtDB); err != nil {
+		return nil, fmt.Errorf("ensure schema state table: %v", err)
+	}
+
+	key := schemaTableKey(cfg.SrcDB, cfg.SrcTable)
+	if schema, ok, err := loadSchemaState(tgtDB, keyFor(cfg)); err != nil {
+		return nil, fmt.Errorf("load schema state: %v", err)
+	} else if ok {
+		st.tables[key] = schema
+		return st, nil
+	}
+
+	schema, err := readTargetTableSchema(tgtDB, cfg.TgtDB, cfg.TargetTable, cfg.SrcDB, cfg.SrcTable)
+	if err != nil {
+		return nil, fmt.Errorf("read initial table schema: %v", err)
+	}
+	st.tables[key] = schema
+	if err := st.persist(); err != nil {
+		return nil, fmt.Errorf("persist initial schema state: %v", err)
+	}
+	return st, nil
+}
+
+// GetTable returns the tracked schema for schema.table (source names), or
+// nil if that table isn't tracked.
+func (st *SchemaTracker) GetTable(schema, table string) *TableSchema {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.tables[schemaTableKey(schema, table)]
+}
+
+// HandleQueryEvent inspects a QueryEvent's SQL text. DDL that doesn't touch
+// the tracked table is ignored. Recognized DDL affecting the tracked table
+// is applied to the target (through the translator) and the in-memory
+// schema is refreshed from the target's new column list. Unsupported DDL
+// shapes affecting the tracked table are reported as an error rather than
+// silently dropped, as is a DROP COLUMN unless cfg.DDLUnsafePolicy allows it
+// (see isUnsafeDropColumnDDL) - a column still referenced by in-flight
+// binlog row events would otherwise apply successfully against the target
+// and then fail, or worse silently misalign, on the next row for that
+// table.
+func (st *SchemaTracker) HandleQueryEvent(eventSchema, query string) error {
+	trimmed := strings.TrimSpace(query)
+	if trimmed == "" || strings.EqualFold(trimmed, "BEGIN") || strings.EqualFold(trimmed, "COMMIT") {
+		return nil
+	}
+	if !isDDLStatement(trimmed) {
+		return nil
+	}
+
+	srcSchema := eventSchema
+	if srcSchema == "" {
+		srcSchema = st.cfg.SrcDB
+	}
+
+	translated, relevant, err := st.translate(srcSchema, st.cfg.SrcTable, trimmed)
+	if err != nil {
+		return err
+	}
+	if !relevant {
+		return nil
+	}
+	if !isSupportedDDL(trimmed) {
+		return fmt.Errorf("unsupported DDL for tracked table %s.%s: %s", st.cfg.SrcDB, st.cfg.SrcTable, trimmed)
+	}
+	if isUnsafeDropColumnDDL(trimmed) {
+		if st.cfg.DDLUnsafePolicy != "allow" {
+			return fmt.Errorf("rejecting unsafe DDL (drops a column) for tracked table %s.%s: %s (set DDL_UNSAFE_POLICY=allow to apply it anyway)", st.cfg.SrcDB, st.cfg.SrcTable, trimmed)
+		}
+		log.Printf("Warning: applying DDL that drops a column, per DDL_UNSAFE_POLICY=allow: %s", trimmed)
+	}
+
+	log.Printf("Applying tracked DDL to target: %s", translated)
+	if _, err := st.tgtDB.Exec(translated); err != nil {
+		return fmt.Errorf("apply DDL to target: %v", err)
+	}
+
+	schema, err := readTargetTableSchema(st.tgtDB, st.cfg.TgtDB, st.cfg.TargetTable, st.cfg.SrcDB, st.cfg.SrcTable)
+	if err != nil {
+		return fmt.Errorf("refresh schema after DDL: %v", err)
+	}
+
+	st.mu.Lock()
+	st.tables[schemaTableKey(st.cfg.SrcDB, st.cfg.SrcTable)] = schema
+	st.mu.Unlock()
+
+	return st.persist()
+}
+
+func (st *SchemaTracker) persist() error {
+	st.mu.RLock()
+	schema := st.tables[schemaTableKey(st.cfg.SrcDB, st.cfg.SrcTable)]
+	st.mu.RUnlock()
+	if schema == nil {
+		return nil
+	}
+	return saveSchemaState(st.tgtDB, keyFor(st.cfg), schema)
+}
+
+func schemaTableKey(schema, table string) string {
+	return fmt.Sprintf("%s.%s", schema, table)
+}
+
+// defaultDDLTranslator mirrors CopyTableSchema's rename approach: a DDL
+// statement is relevant when it names the source table (schema-qualified or
+// not), and is translated by rewriting references to the source schema/table
+// with the target schema/table.
+func defaultDDLTranslator(cfg Config) ddlTranslator {
+	return func(srcSchema, srcTable, query string) (string, bool, error) {
+		if !ddlReferencesTable(query, srcSchema, srcTable) {
+			return "", false, nil
+		}
+		translated := query
+		translated = strings.Replace(translated, fmt.Sprintf("`%s`.`%s`", srcSchema, srcTable), fmt.Sprintf("`%s`.`%s`", cfg.TgtDB, cfg.TargetTable), 1)
+		translated = strings.Replace(translated, fmt.Sprintf("`%s`", srcTable), fmt.Sprintf("`%s`", cfg.TargetTable), 1)
+		translated = replaceBareIdentifier(translated, srcTable, cfg.TargetTable)
+		return translated, true, nil
+	}
+}
+
+// replaceBareIdentifier replaces the first occurrence of name in s that
+// isn't adjacent to another identifier character on either side, so it
+// rewrites an unquoted table reference without clipping a longer
+// identifier that merely contains name as a substring - e.g. a column
+// named "<table>_ref" sitting right next to an already-translated table
+// reference in the same ALTER TABLE statement.
+func replaceBareIdentifier(s, name, repl string) string {
+	isIdentChar := func(b byte) bool {
+		return b == '_' || b == '$' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+	}
+	for start := 0; ; {
+		i := strings.Index(s[start:], name)
+		if i == -1 {
+			return s
+		}
+		pos := start + i
+		end := pos + len(name)
+		before := pos == 0 || !isIdentChar(s[pos-1])
+		after := end == len(s) || !isIdentChar(s[end])
+		if before && after {
+			return s[:pos] + repl + s[end:]
+		}
+		start = pos + 1
+	}
+}
+
+var ddlStmtPattern = regexp.MustCompile(`(?i)^\s*(CREATE|ALTER|DROP|RENAME|TRUNCATE)\s`)
+
+func isDDLStatement(query string) bool {
+	return ddlStmtPattern.MatchString(query)
+}
+
+// ddlReferencesTable reports whether a DDL statement names the given table,
+// schema-qualified or bare, backtick-quoted or not.
+func ddlReferencesTable(query, schema, table string) bool {
+	candidates := []string{
+		fmt.Sprintf("`%s`.`%s`", schema, table),
+		fmt.Sprintf("`%s`", table),
+		table,
+	}
+	for _, c := range candidates {
+		if strings.Contains(query, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// Recognized ALTER TABLE clause shapes. Anything else affecting the tracked
+// table is reported as unsupported rather than silently applied/dropped.
+var supportedAlterClause = regexp.MustCompile(`(?i)^\s*ALTER\s+TABLE\s+\S+\s+(ADD|DROP|MODIFY|CHANGE|RENAME)\b`)
+var supportedOtherDDL = regexp.MustCompile(`(?i)^\s*(CREATE\s+TABLE|DROP\s+TABLE|TRUNCATE\s+TABLE|RENAME\s+TABLE)\b`)
+
+func isSupportedDDL(query string) bool {
+	return supportedAlterClause.MatchString(query) || supportedOtherDDL.MatchString(query)
+}
+
+// dropColumnPattern matches an ALTER TABLE clause dropping a named column;
+// nonColumnDropPattern excludes the DROP shapes that don't remove a column
+// (index/key/constraint), which the same ALTER TABLE ... DROP ... grammar
+// also covers.
+var dropColumnPattern = regexp.MustCompile("(?i)\\bDROP\\s+(COLUMN\\s+)?`?[A-Za-z0-9_]+`?")
+var nonColumnDropPattern = regexp.MustCompile(`(?i)\bDROP\s+(TABLE|INDEX|KEY|PRIMARY\s+KEY|FOREIGN\s+KEY|CONSTRAINT)\b`)
+
+// isUnsafeDropColumnDDL reports whether query drops a column from the
+// tracked table. A dropped column that's still referenced by row events
+// already in flight on the binlog would otherwise desync decodeRowsEvent's
+// column list from what those events actually carry, so HandleQueryEvent
+// gates this behind cfg.DDLUnsafePolicy instead of applying it unconditionally.
+func isUnsafeDropColumnDDL(query string) bool {
+	upper := strings.ToUpper(query)
+	if !strings.Contains(upper, "DROP") {
+		return false
+	}
+	if nonColumnDropPattern.MatchString(query) {
+		return false
+	}
+	return dropColumnPattern.MatchString(query)
+}
+
+// readTargetTableSchema reads the current column list/types/charsets of the
+// target table from information_schema, labeling the result with the
+// source schema/table so GetTable lookups (keyed by source names, since
+// that's what the binlog stream reports) still resolve.
+func readTargetTableSchema(db *sql.DB, tgtSchema, tgtTable, srcSchemaLabel, srcTableLabel string) (*TableSchema, error) {
+	q := `
+SELECT COLUMN_NAME, DATA_TYPE, COALESCE(CHARACTER_SET_NAME, '')
+FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+ORDER BY ORDINAL_POSITION`
+	rows, err := db.Query(q, tgtSchema, tgtTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schema := &TableSchema{Schema: srcSchemaLabel, Table: srcTableLabel}
+	for rows.Next() {
+		var c ColumnInfo
+		if err := rows.Scan(&c.Name, &c.DataType, &c.Charset); err != nil {
+			return nil, err
+		}
+		schema.Columns = append(schema.Columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	pkCols, err := getPrimaryKeyColumns(db, tgtSchema, tgtTable)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkCols) == 0 && len(schema.Columns) > 0 {
+		pkCols = []string{schema.Columns[0].Name}
+	}
+	schema.PKCols = pkCols
+
+	return schema, nil
+}
+
+// EnsureSchemaStateTable creates the table that persists tracked schema
+// state alongside the binlog position checkpoint, so it survives restarts.
+func EnsureSchemaStateTable(db *sql.DB) error {
+	q := `
+CREATE TABLE IF NOT EXISTS cdc_schema_state (
+  table_key VARCHAR(255) PRIMARY KEY,
+  columns_json TEXT,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+)`
+	_, err := db.Exec(q)
+	return err
+}
+
+func saveSchemaState(db *sql.DB, key string, schema *TableSchema) error {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	q := `INSERT INTO cdc_schema_state (table_key, columns_json) VALUES (?, ?)
+ON DUPLICATE KEY UPDATE columns_json=VALUES(columns_json)`
+	_, err = db.Exec(q, key, string(data))
+	return err
+}
+
+func loadSchemaState(db *sql.DB, key string) (*TableSchema, bool, error) {
+	var data string
+	err := db.QueryRow(`SELECT columns_json FROM cdc_schema_state WHERE table_key = ?`, key).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var schema TableSchema
+	if err := json.Unmarshal([]byte(data), &schema); err != nil {
+		return nil, false, err
+	}
+	return &schema, true, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestIsDDLStatement(t *testing.T) {
+	cases := map[string]bool{
+		"ALTER TABLE `orders` ADD COLUMN foo INT": true,
+		"CREATE TABLE `orders` (id INT)":          true,
+		"DROP TABLE `orders`":                     true,
+		"INSERT INTO orders VALUES (1)":           false,
+		"BEGIN":                                   false,
+	}
+	for q, want := range cases {
+		if got := isDDLStatement(q); got != want {
+			t.Errorf("isDDLStatement(%q) = %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestDdlReferencesTable(t *testing.T) {
+	if !ddlReferencesTable("ALTER TABLE `shop`.`orders` ADD COLUMN foo INT", "shop", "orders") {
+		t.Error("expected schema-qualified reference to match")
+	}
+	if !ddlReferencesTable("ALTER TABLE `orders` ADD COLUMN foo INT", "shop", "orders") {
+		t.Error("expected bare backtick-quoted reference to match")
+	}
+	if ddlReferencesTable("ALTER TABLE `customers` ADD COLUMN foo INT", "shop", "orders") {
+		t.Error("did not expect unrelated table to match")
+	}
+}
+
+func TestIsSupportedDDL(t *testing.T) {
+	supported := []string{
+		"ALTER TABLE `orders` ADD COLUMN foo INT",
+		"ALTER TABLE `orders` DROP COLUMN foo",
+		"ALTER TABLE `orders` MODIFY COLUMN foo BIGINT",
+		"ALTER TABLE `orders` CHANGE foo bar INT",
+		"RENAME TABLE `orders` TO `orders_old`",
+	}
+	for _, q := range supported {
+		if !isSupportedDDL(q) {
+			t.Errorf("expected %q to be recognized as supported DDL", q)
+		}
+	}
+
+	if isSupportedDDL("ALTER TABLE `orders` PARTITION BY RANGE (id) (PARTITION p0 VALUES LESS THAN (100))") {
+		t.Error("expected unrecognized ALTER clause to be reported unsupported")
+	}
+}
+
+func TestIsUnsafeDropColumnDDL(t *testing.T) {
+	unsafe := []string{
+		"ALTER TABLE `orders` DROP COLUMN `foo`",
+		"ALTER TABLE `orders` DROP `foo`",
+	}
+	for _, q := range unsafe {
+		if !isUnsafeDropColumnDDL(q) {
+			t.Errorf("expected %q to be reported unsafe", q)
+		}
+	}
+
+	safe := []string{
+		"DROP TABLE `orders`",
+		"ALTER TABLE `orders` DROP INDEX idx_foo",
+		"ALTER TABLE `orders` DROP PRIMARY KEY",
+		"ALTER TABLE `orders` DROP FOREIGN KEY fk_foo",
+		"ALTER TABLE `orders` ADD COLUMN `bar` INT",
+		"RENAME TABLE `orders` TO `orders_old`",
+	}
+	for _, q := range safe {
+		if isUnsafeDropColumnDDL(q) {
+			t.Errorf("did not expect %q to be reported unsafe", q)
+		}
+	}
+}
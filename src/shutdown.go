@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ErrShutdown is returned by full-load and CDC loops when they abort early
+// because a shutdown signal arrived, so callers can exit cleanly (status 0)
+// instead of treating it as a failed attempt to retry.
+var ErrShutdown = errors.New("shutdown requested")
+
+// shuttingDown flips to 1 the instant a shutdown signal is received, so
+// /ready can start failing immediately and the load balancer stops routing
+// before anything else unwinds.
+var shuttingDown int32
+
+func markNotReady() {
+	atomic.StoreInt32(&shuttingDown, 1)
+}
+
+func isShuttingDown() bool {
+	return atomic.LoadInt32(&shuttingDown) == 1
+}
+
+// ShutdownCoordinator arms a context that is cancelled on SIGINT/SIGTERM and
+// tracks in-flight apply work, implementing the standard lame-duck pattern
+// for zero-loss rolling deploys: stop taking new work, let the current
+// batch finish (bounded by LameDuckSeconds), checkpoint, then exit.
+type ShutdownCoordinator struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	lameDuck time.Duration
+	inFlight sync.WaitGroup
+}
+
+// NewShutdownCoordinator installs a signal handler for SIGINT/SIGTERM. The
+// returned coordinator's Context is cancelled the moment a signal arrives.
+func NewShutdownCoordinator(lameDuckSeconds int) *ShutdownCoordinator {
+	ctx, cancel := context.WithCancel(context.Background())
+	sc := &ShutdownCoordinator{
+		ctx:      ctx,
+		cancel:   cancel,
+		lameDuck: time.Duration(lameDuckSeconds) * time.Second,
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigs
+		log.Printf("Received %s, starting graceful shutdown (lame-duck %s)", sig, sc.lameDuck)
+		markNotReady()
+		cancel()
+	}()
+
+	return sc
+}
+
+// Context is cancelled as soon as a shutdown signal arrives.
+func (sc *ShutdownCoordinator) Context() context.Context {
+	return sc.ctx
+}
+
+// TrackApply marks one unit of in-flight apply work as started; call the
+// returned func when it completes.
+func (sc *ShutdownCoordinator) TrackApply() func() {
+	sc.inFlight.Add(1)
+	return sc.inFlight.Done
+}
+
+// WaitForDrain blocks until all tracked in-flight work finishes or the
+// lame-duck period elapses, whichever comes first.
+func (sc *ShutdownCoordinator) WaitForDrain() {
+	drained := make(chan struct{})
+	go func() {
+		sc.inFlight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(sc.lameDuck):
+		log.Println("Lame-duck period elapsed with work still in flight, proceeding to final checkpoint")
+	}
+}
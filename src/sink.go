@@ -0,0 +1,324 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	_ "github.com/lib/pq"
+)
+
+// TargetSink abstracts where full-load schema and rows are written to, so
+// the replication target does not have to be MySQL. The implementation is
+// selected from the scheme prefix of TGT_DSN (mysql://, postgres://,
+// file://); a DSN with no recognized scheme is treated as mysql:// for
+// backward compatibility with every existing deployment's TGT_DSN. This is
+// already what makes loadRange, streamingLoad and chunkedKeyLoad
+// sink-agnostic: none of them know or care which TargetSink implementation
+// they're handed.
+//
+// CDC row-level apply is a separate concern from full load and is not
+// routed through TargetSink; see CDCSink in cdc_sink.go instead. The two
+// interfaces stay distinct because full load writes column-ordered batches
+// while CDC applies individually-typed insert/update/delete operations, but
+// both follow the same rule below for where checkpoints live.
+//
+// Checkpoint bookkeeping (the binlog file/pos, or GTID set, captured after
+// full load) deliberately does NOT go through TargetSink - see the comment
+// in main.go next to NewTargetSink. Every TargetSink, including a data-lake
+// FileSink, resumes CDC from the same MySQL-resident checkpoint table,
+// rather than each sink implementation having to know how to durably store
+// and read back a binlog position itself.
+type TargetSink interface {
+	// Name identifies the sink implementation for logging.
+	Name() string
+	// PrepareSchema creates or translates the target table from the
+	// source's current schema, ahead of the first WriteBatch call.
+	PrepareSchema(srcDB *sql.DB, cfg Config) error
+	// WriteBatch writes a batch of full-load rows, values in the same
+	// column order as cols.
+	WriteBatch(cfg Config, cols []string, rows [][]interface{}) error
+	Close() error
+}
+
+// sinkScheme splits a TGT_DSN into its scheme ("mysql", "postgres", "file")
+// and the remainder of the DSN/path, defaulting to "mysql" for schemeless
+// DSNs.
+func sinkScheme(dsn string) (scheme, rest string) {
+	for _, prefix := range []string{"mysql://", "postgres://", "file://"} {
+		if strings.HasPrefix(dsn, prefix) {
+			return strings.TrimSuffix(prefix, "://"), strings.TrimPrefix(dsn, prefix)
+		}
+	}
+	return "mysql", dsn
+}
+
+// NewTargetSink builds the TargetSink selected by cfg.TgtDSN's scheme.
+// mysqlDB is reused as-is for MySQLSink, since it's already the connection
+// the rest of the tool uses for checkpoints and progress tracking; the
+// postgres and file sinks open their own connection/handle instead.
+func NewTargetSink(cfg Config, mysqlDB *sql.DB) (TargetSink, error) {
+	scheme, rest := sinkScheme(cfg.TgtDSN)
+	switch scheme {
+	case "mysql":
+		return &MySQLSink{db: mysqlDB, chunker: newAdaptiveChunkSize(cfg)}, nil
+	case "postgres":
+		db, err := sql.Open("postgres", rest)
+		if err != nil {
+			return nil, fmt.Errorf("open postgres sink: %v", err)
+		}
+		return &PostgresSink{db: db}, nil
+	case "file":
+		return newFileSink(rest, cfg.ParallelWorkers)
+	default:
+		return nil, fmt.Errorf("unsupported target sink scheme: %s", scheme)
+	}
+}
+
+// MySQLSink is the tool's original behavior: schema copied verbatim via
+// CopyTableSchema, rows written with the same batched INSERT used before
+// TargetSink existed, or with LOAD DATA LOCAL INFILE when cfg.LoadMethod is
+// "load_data_infile". chunker is shared across every WriteBatch call for
+// the life of the sink, since it's what lets executeBatchInsert's adaptive
+// chunk size actually settle on a steady-state value instead of resetting
+// every batch.
+type MySQLSink struct {
+	db      *sql.DB
+	chunker *adaptiveChunkSize
+}
+
+func (s *MySQLSink) Name() string { return "mysql" }
+
+func (s *MySQLSink) PrepareSchema(srcDB *sql.DB, cfg Config) error {
+	if cfg.LoadMethod == "load_data_infile" {
+		if err := ensureLocalInfileEnabled(s.db); err != nil {
+			return err
+		}
+	}
+	return CopyTableSchema(srcDB, s.db, cfg.SrcDB, cfg.SrcTable, cfg.TgtDB, cfg.TargetTable)
+}
+
+func (s *MySQLSink) WriteBatch(cfg Config, cols []string, rows [][]interface{}) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if cfg.LoadMethod == "load_data_infile" {
+		err = loadDataInfile(tx, cfg, cols, rows)
+	} else {
+		err = executeBatchInsert(tx, cfg, cols, rows, s.chunker)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Close is a no-op: the *sql.DB is owned by the caller (main opens and
+// closes it regardless of which sink ends up using it).
+func (s *MySQLSink) Close() error { return nil }
+
+// PostgresSink translates the source table's column types to their nearest
+// Postgres equivalent and writes rows via parameterized INSERTs.
+type PostgresSink struct {
+	db *sql.DB
+}
+
+func (s *PostgresSink) Name() string { return "postgres" }
+
+func (s *PostgresSink) PrepareSchema(srcDB *sql.DB, cfg Config) error {
+	cols, err := readSourceColumnTypes(srcDB, cfg.SrcDB, cfg.SrcTable)
+	if err != nil {
+		return fmt.Errorf("read source column types: %v", err)
+	}
+	if len(cols) == 0 {
+		return fmt.Errorf("source table %s.%s has no columns", cfg.SrcDB, cfg.SrcTable)
+	}
+	var defs []string
+	for _, c := range cols {
+		defs = append(defs, fmt.Sprintf("%q %s", c.Name, mysqlTypeToPostgres(c.DataType)))
+	}
+	ddl := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %q (%s)", cfg.TargetTable, strings.Join(defs, ", "))
+	_, err = s.db.Exec(ddl)
+	return err
+}
+
+func (s *PostgresSink) WriteBatch(cfg Config, cols []string, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	var qCols []string
+	for _, c := range cols {
+		qCols = append(qCols, fmt.Sprintf("%q", c))
+	}
+	var placeholders []string
+	for i := range cols {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
+	}
+	q := fmt.Sprintf("INSERT INTO %q (%s) VALUES (%s) ON CONFLICT DO NOTHING",
+		cfg.TargetTable, strings.Join(qCols, ","), strings.Join(placeholders, ","))
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresSink) Close() error { return s.db.Close() }
+
+// sourceColumn is the subset of column metadata PostgresSink needs to
+// translate a MySQL table definition.
+type sourceColumn struct {
+	Name     string
+	DataType string
+}
+
+func readSourceColumnTypes(db *sql.DB, schema, table string) ([]sourceColumn, error) {
+	rows, err := db.Query(
+		`SELECT COLUMN_NAME, DATA_TYPE FROM INFORMATION_SCHEMA.COLUMNS
+WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []sourceColumn
+	for rows.Next() {
+		var c sourceColumn
+		if err := rows.Scan(&c.Name, &c.DataType); err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+// mysqlTypeToPostgres maps a MySQL INFORMATION_SCHEMA.COLUMNS DATA_TYPE to
+// its nearest Postgres equivalent. Unrecognized types fall back to TEXT
+// rather than failing schema creation outright.
+func mysqlTypeToPostgres(mysqlType string) string {
+	switch strings.ToLower(mysqlType) {
+	case "tinyint", "smallint":
+		return "SMALLINT"
+	case "int", "mediumint":
+		return "INTEGER"
+	case "bigint":
+		return "BIGINT"
+	case "float":
+		return "REAL"
+	case "double", "decimal", "numeric":
+		return "DOUBLE PRECISION"
+	case "datetime", "timestamp":
+		return "TIMESTAMP"
+	case "date":
+		return "DATE"
+	case "time":
+		return "TIME"
+	case "tinyblob", "blob", "mediumblob", "longblob", "binary", "varbinary":
+		return "BYTEA"
+	case "json":
+		return "JSONB"
+	default:
+		// varchar, char, text variants, enum, set, and anything else this
+		// tool hasn't special-cased.
+		return "TEXT"
+	}
+}
+
+// FileSink writes full-load rows as newline-delimited JSON, one object per
+// row keyed by column name, fanning the data out into a local data-lake
+// layout: numShards files instead of one, so the loadRange/chunkedKeyLoad
+// worker pool's concurrent WriteBatch calls aren't serialized through a
+// single file handle. WriteBatch round-robins across shards; each shard
+// has its own mutex, so only writers that land on the same shard ever
+// contend.
+type FileSink struct {
+	shards []*fileShard
+	next   uint64
+}
+
+// fileShard is one NDJSON output file and the encoder writing to it.
+type fileShard struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// newFileSink opens numShards NDJSON files named path itself for a single
+// shard, or path.shardNNNN.ndjson for shard N when numShards > 1 - so the
+// common single-worker case keeps the plain, predictable filename.
+func newFileSink(path string, numShards int) (*FileSink, error) {
+	if numShards < 1 {
+		numShards = 1
+	}
+	shards := make([]*fileShard, 0, numShards)
+	for i := 0; i < numShards; i++ {
+		shardPath := path
+		if numShards > 1 {
+			shardPath = fmt.Sprintf("%s.shard%04d.ndjson", path, i)
+		}
+		f, err := os.Create(shardPath)
+		if err != nil {
+			for _, s := range shards {
+				s.f.Close()
+			}
+			return nil, fmt.Errorf("open file sink shard %d: %v", i, err)
+		}
+		shards = append(shards, &fileShard{f: f, enc: json.NewEncoder(f)})
+	}
+	return &FileSink{shards: shards}, nil
+}
+
+func (s *FileSink) Name() string { return "file" }
+
+// PrepareSchema is a no-op: each NDJSON line is self-describing, so there's
+// no DDL to create ahead of time.
+func (s *FileSink) PrepareSchema(srcDB *sql.DB, cfg Config) error { return nil }
+
+func (s *FileSink) WriteBatch(cfg Config, cols []string, rows [][]interface{}) error {
+	idx := atomic.AddUint64(&s.next, 1) % uint64(len(s.shards))
+	shard := s.shards[idx]
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	for _, row := range rows {
+		rec := make(map[string]interface{}, len(cols))
+		for i, c := range cols {
+			if i < len(row) {
+				rec[c] = row[i]
+			}
+		}
+		if err := shard.enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileSink) Close() error {
+	var firstErr error
+	for _, shard := range s.shards {
+		if err := shard.f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ThrottleDecision is the outcome of a Throttler check.
+type ThrottleDecision int
+
+const (
+	ThrottleGo ThrottleDecision = iota
+	ThrottleSleep
+	ThrottleCritical
+)
+
+// Throttler decides whether full-load and CDC apply should pause, based on
+// replica lag, a custom SQL probe, a flag file, or source thread load. This
+// mirrors gh-ost's throttling model so replication against busy production
+// sources doesn't saturate them.
+type Throttler struct {
+	cfg       Config
+	srcDB     *sql.DB
+	replicaDB *sql.DB // opened once for ThrottleReplicaDSN and reused by every Check, instead of per-probe
+
+	mu     sync.RWMutex
+	reason string
+}
+
+// NewThrottler builds a Throttler from config. It always returns a non-nil
+// Throttler; with no throttling sources configured, Check returns ThrottleGo.
+// A failure to open ThrottleReplicaDSN here isn't fatal - replicaLagSeconds
+// logs and skips the lag probe the same way a later connection error would,
+// so the rest of throttling still works.
+func NewThrottler(cfg Config, srcDB *sql.DB) *Throttler {
+	t := &Throttler{cfg: cfg, srcDB: srcDB, reason: ""}
+	if cfg.ThrottleReplicaDSN != "" {
+		if db, err := OpenDB(cfg.ThrottleReplicaDSN); err != nil {
+			log.Printf("Throttler: failed to open replica DSN, lag probing disabled: %v", err)
+		} else {
+			t.replicaDB = db
+		}
+	}
+	return t
+}
+
+// Close releases the replica connection pool opened for lag probing, if any.
+func (t *Throttler) Close() error {
+	if t == nil || t.replicaDB == nil {
+		return nil
+	}
+	return t.replicaDB.Close()
+}
+
+// Reason returns the human-readable reason recorded by the most recent Check,
+// or "" when that check returned ThrottleGo; Wait surfaces it via /metrics so
+// operators can see why throughput dropped.
+func (t *Throttler) Reason() string {
+	if t == nil {
+		return ""
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.reason
+}
+
+func (t *Throttler) setReason(r string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reason = r
+}
+
+// Check consults all configured throttling sources and returns a decision
+// plus how long to sleep (for ThrottleSleep).
+func (t *Throttler) Check() (ThrottleDecision, time.Duration) {
+	if t == nil {
+		return ThrottleGo, 0
+	}
+
+	if t.cfg.ThrottleFlagFile != "" {
+		if _, err := os.Stat(t.cfg.ThrottleFlagFile); err == nil {
+			t.setReason("throttle flag file present: " + t.cfg.ThrottleFlagFile)
+			return ThrottleSleep, time.Second
+		}
+	}
+
+	if t.replicaDB != nil {
+		lagSec, err := t.replicaLagSeconds()
+		if err != nil {
+			log.Printf("Throttler: replica lag probe failed: %v", err)
+		} else {
+			// globalMetrics.ReplicationLagSec is the only measured replica
+			// lag this tool has; CutoverCoordinator's lag gate (cutover.go)
+			// reads it directly, so it has to be kept current here or that
+			// gate can never fire.
+			globalMetrics.UpdateReplicationLag(float64(lagSec))
+			if lagSec >= t.cfg.ThrottleLagHardSec {
+				t.setReason("replica lag critical: Seconds_Behind_Master exceeds hard threshold")
+				return ThrottleCritical, 0
+			} else if lagSec >= t.cfg.ThrottleLagSoftSec {
+				t.setReason("replica lag above soft threshold")
+				return ThrottleSleep, time.Second
+			}
+		}
+	}
+
+	if t.cfg.ThrottleQuery != "" {
+		var val int
+		if err := t.srcDB.QueryRow(t.cfg.ThrottleQuery).Scan(&val); err != nil {
+			log.Printf("Throttler: THROTTLE_QUERY failed: %v", err)
+		} else if val > 0 {
+			t.setReason("THROTTLE_QUERY returned > 0")
+			return ThrottleSleep, time.Second
+		}
+	}
+
+	if t.cfg.MaxLoadThreadsRunning > 0 {
+		running, err := t.threadsRunning()
+		if err != nil {
+			log.Printf("Throttler: Threads_running probe failed: %v", err)
+		} else if running > t.cfg.MaxLoadThreadsRunning {
+			t.setReason("source Threads_running exceeds MAX_LOAD")
+			return ThrottleSleep, time.Second
+		}
+	}
+
+	t.setReason("")
+	return ThrottleGo, 0
+}
+
+// Wait blocks the caller until the throttler returns ThrottleGo, sleeping
+// between checks. It returns an error if a critical condition is hit.
+func (t *Throttler) Wait() error {
+	if t == nil {
+		return nil
+	}
+	for {
+		decision, sleep := t.Check()
+		globalMetrics.UpdateThrottleReason(t.Reason())
+		switch decision {
+		case ThrottleGo:
+			return nil
+		case ThrottleCritical:
+			return &ThrottleCriticalError{Reason: t.Reason()}
+		case ThrottleSleep:
+			time.Sleep(sleep)
+		}
+	}
+}
+
+// ThrottleCriticalError signals that replication must stop rather than keep
+// throttling down, e.g. replica lag has blown past the hard threshold.
+type ThrottleCriticalError struct {
+	Reason string
+}
+
+func (e *ThrottleCriticalError) Error() string {
+	return "throttle: critical stop: " + e.Reason
+}
+
+func (t *Throttler) replicaLagSeconds() (int64, error) {
+	rows, err := t.replicaDB.Query("SHOW SLAVE STATUS")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		return 0, nil
+	}
+	values := make([]sql.RawBytes, len(cols))
+	scanArgs := make([]interface{}, len(values))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	if err := rows.Scan(scanArgs...); err != nil {
+		return 0, err
+	}
+	for i, col := range cols {
+		if strings.EqualFold(col, "Seconds_Behind_Master") {
+			if values[i] == nil {
+				return 0, nil
+			}
+			return strconv.ParseInt(string(values[i]), 10, 64)
+		}
+	}
+	return 0, nil
+}
+
+func (t *Throttler) threadsRunning() (int, error) {
+	row := t.srcDB.QueryRow("SHOW GLOBAL STATUS LIKE 'Threads_running'")
+	var name string
+	var val int
+	if err := row.Scan(&name, &val); err != nil {
+		return 0, err
+	}
+	return val, nil
+}
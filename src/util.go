@@ -117,50 +117,8 @@ func CopyTableSchema(src *sql.DB, tgt *sql.DB, srcSchema, srcTable, tgtSchema, t
 	return nil
 }
 
-func extractHostFromDSN(dsn string) string {
-	// DSN format: user:pass@tcp(host:port)/dbname
-	if idx := strings.Index(dsn, "@tcp("); idx != -1 {
-		rest := dsn[idx+5:]
-		if end := strings.Index(rest, ":"); end != -1 {
-			return rest[:end]
-		}
-	}
-	return "127.0.0.1"
-}
-
-func extractPortFromDSN(dsn string) uint16 {
-	// DSN format: user:pass@tcp(host:port)/dbname
-	if idx := strings.Index(dsn, "@tcp("); idx != -1 {
-		rest := dsn[idx+5:]
-		if start := strings.Index(rest, ":"); start != -1 {
-			if end := strings.Index(rest[start+1:], ")"); end != -1 {
-				port := rest[start+1 : start+1+end]
-				var p uint16
-				fmt.Sscanf(port, "%d", &p)
-				return p
-			}
-		}
-	}
-	return 3306
-}
-
-func extractUserFromDSN(dsn string) string {
-	// DSN format: user:pass@tcp(host:port)/dbname
-	if idx := strings.Index(dsn, ":"); idx != -1 {
-		return dsn[:idx]
-	}
-	return "root"
-}
-
-func extractPassFromDSN(dsn string) string {
-	// DSN format: user:pass@tcp(host:port)/dbname
-	if start := strings.Index(dsn, ":"); start != -1 {
-		if end := strings.Index(dsn[start+1:], "@"); end != -1 {
-			return dsn[start+1 : start+1+end]
-		}
-	}
-	return ""
-}
+// extractHostFromDSN, extractPortFromDSN, extractUserFromDSN, and
+// extractPassFromDSN live in dsn.go, backed by go-sql-driver's ParseDSN.
 
 func getSourceMasterStatus(db *sql.DB) (string, uint32, error) {
 	row := db.QueryRow("SHOW MASTER STATUS")
@@ -172,3 +130,23 @@ func getSourceMasterStatus(db *sql.DB) (string, uint32, error) {
 	}
 	return file, pos, nil
 }
+
+// GTIDModeEnabled reports whether the source has gtid_mode=ON, so callers can
+// decide whether to resume replication via GTID instead of file/pos.
+func GTIDModeEnabled(db *sql.DB) (bool, error) {
+	var mode string
+	if err := db.QueryRow("SELECT @@GLOBAL.gtid_mode").Scan(&mode); err != nil {
+		return false, err
+	}
+	return strings.ToUpper(mode) == "ON", nil
+}
+
+// GetSourceGTIDSet returns the source's current executed GTID set, or an
+// empty string when GTID mode is off.
+func GetSourceGTIDSet(db *sql.DB) (string, error) {
+	var gtidSet sql.NullString
+	if err := db.QueryRow("SELECT @@GLOBAL.gtid_executed").Scan(&gtidSet); err != nil {
+		return "", err
+	}
+	return gtidSet.String, nil
+}
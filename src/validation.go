@@ -39,7 +39,25 @@ func ValidateConfig(cfg Config) error {
 	if cfg.CheckpointPeriod <= 0 {
 		return fmt.Errorf("CHECKPOINT_PERIOD must be greater than 0, got %d", cfg.CheckpointPeriod)
 	}
-	
+	if cfg.MinChunkSize <= 0 {
+		return fmt.Errorf("MIN_CHUNK_SIZE must be greater than 0, got %d", cfg.MinChunkSize)
+	}
+	if cfg.MaxChunkSize < cfg.MinChunkSize {
+		return fmt.Errorf("MAX_CHUNK_SIZE (%d) must be >= MIN_CHUNK_SIZE (%d)", cfg.MaxChunkSize, cfg.MinChunkSize)
+	}
+	if cfg.MaxInFlightConns <= 0 {
+		return fmt.Errorf("MAX_INFLIGHT_CONNS must be greater than 0, got %d", cfg.MaxInFlightConns)
+	}
+	if cfg.MaxInFlightRows <= 0 {
+		return fmt.Errorf("MAX_INFLIGHT_ROWS must be greater than 0, got %d", cfg.MaxInFlightRows)
+	}
+	if cfg.BatchMaxRows <= 0 {
+		return fmt.Errorf("BATCH_MAX_ROWS must be greater than 0, got %d", cfg.BatchMaxRows)
+	}
+	if cfg.BatchMaxBytes <= 0 {
+		return fmt.Errorf("BATCH_MAX_BYTES must be greater than 0, got %d", cfg.BatchMaxBytes)
+	}
+
 	// Validate server ID (must be unique in replication topology)
 	if cfg.ServerID == 0 {
 		log.Println("Warning: SERVER_ID is 0, using default 9999")
@@ -76,7 +94,18 @@ func ValidateSourceDatabase(srcDB *sql.DB, cfg Config) error {
 		return fmt.Errorf("binary logging is not enabled. Set log_bin=ON in MySQL config")
 	}
 	log.Println("✓ Binary logging is enabled")
-	
+
+	// GTID mode is optional but preferred: when enabled, CDC can resume
+	// across source failover using the executed GTID set instead of
+	// file/pos coordinates.
+	if gtidEnabled, err := GTIDModeEnabled(srcDB); err != nil {
+		log.Printf("Warning: could not determine gtid_mode: %v", err)
+	} else if gtidEnabled {
+		log.Println("✓ GTID mode is enabled, CDC will prefer GTID-based resumption")
+	} else {
+		log.Println("GTID mode is disabled, falling back to binlog file/pos checkpointing")
+	}
+
 	// Check if source table exists
 	var tableExists int
 	query := fmt.Sprintf("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?")
@@ -22,6 +22,12 @@ func TestValidateConfig(t *testing.T) {
 				BatchSize:        1000,
 				Workers:          4,
 				CheckpointPeriod: 5,
+				MinChunkSize:     100,
+				MaxChunkSize:     5000,
+				MaxInFlightConns: 8,
+				MaxInFlightRows:  200000,
+				BatchMaxRows:     1000,
+				BatchMaxBytes:    4 * 1024 * 1024,
 			},
 			shouldErr: false,
 		},